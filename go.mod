@@ -0,0 +1,3 @@
+module github.com/daominah/GoLLRB
+
+go 1.23
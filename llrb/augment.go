@@ -0,0 +1,108 @@
+package llrb
+
+// Augment keeps an aggregate of type A for every subtree of a tree, such as the sum
+// or the maximum of its elements or the number of its flagged elements, the way the
+// tree keeps NDescendants. combine computes the aggregate of a subtree from its root
+// element and the aggregates of its left and right subtrees, the zero value of A
+// standing for an empty subtree, e.g. for a sum:
+//
+//	sum := NewAugment(func(item int, left, right int) int { return left + item + right })
+//	tree.SetAugment(sum)
+//	total := sum.Of(tree.Root())
+//
+// The tree calls combine again on every node whose subtree changes: on the path of an
+// insert or a delete, for the nodes of a rotation, for the nodes built by a split,
+// a join or a bulk load, from the bottom up. combine must not modify the tree.
+type Augment[T, A any] struct {
+	combine func(item T, left, right A) A
+}
+
+// NewAugment returns an Augment that computes aggregates with combine.
+func NewAugment[T, A any](combine func(item T, left, right A) A) *Augment[T, A] {
+	if combine == nil {
+		panic("nil combine function")
+	}
+	return &Augment[T, A]{combine: combine}
+}
+
+// Of returns the aggregate of the subtree h, or the zero value of A if h is nil
+// or does not hold an aggregate of type A, such as a node of a tree that does not
+// keep the aggregates of a, see SetAugment.
+func (a *Augment[T, A]) Of(h *TreeNode[T]) A {
+	if h != nil {
+		if p, ok := h.aggregate.(*A); ok {
+			return *p
+		}
+	}
+	var zero A
+	return zero
+}
+
+// Augmenter is the augmentation of a tree, it is implemented by Augment and by the
+// sizes that every tree keeps in NDescendants.
+type Augmenter[T any] interface {
+	// update computes the aggregate of h from its item and its children.
+	update(h *TreeNode[T])
+	// detach gives h, a copy of a node, its own aggregate.
+	detach(h *TreeNode[T])
+}
+
+// Each node points to its aggregate, an A of the Augment of its tree, so update
+// writes it in place instead of boxing a new A, and a node copied by Tree.mutable
+// copies it. A node that holds no *A, or the aggregate of another type, gets a new one.
+
+func (a *Augment[T, A]) update(h *TreeNode[T]) {
+	p, ok := h.aggregate.(*A)
+	if !ok {
+		p = new(A)
+		h.aggregate = p
+	}
+	*p = a.combine(h.Item, a.Of(h.Left), a.Of(h.Right))
+}
+
+func (a *Augment[T, A]) detach(h *TreeNode[T]) {
+	if p, ok := h.aggregate.(*A); ok {
+		c := *p
+		h.aggregate = &c
+	}
+}
+
+// sizes is the augmentation that every tree keeps, before the one of SetAugment: the
+// aggregate of a subtree is its number of elements, NDescendants = left + 1 + right.
+// It is built in rather than an Augment[T, int] so that the rank queries read an int
+// field of the node on their path, without an indirection or a dynamic call.
+type sizes[T any] struct{}
+
+var _ Augmenter[Item] = sizes[Item]{}
+
+func (sizes[T]) update(h *TreeNode[T]) {
+	h.NDescendants = size(h.Left) + size(h.Right) + 1
+}
+
+// detach has nothing to do: NDescendants is copied with the node.
+func (sizes[T]) detach(*TreeNode[T]) {}
+
+// SetAugment makes the tree keep the aggregates of a for all its subtrees, in place of
+// those of the previous augmentation, nil removes it. It computes them in O(n), the
+// inserts, deletes and rotations then keep them up to date in O(1) per changed node.
+// The trees made from t by Split, JoinTree, Clone and the set operations keep a too.
+func (t *Tree[T]) SetAugment(a Augmenter[T]) {
+	t.augment = nil // the aggregates of the previous augmentation are dropped, not copied
+	t.root = t.reaugment(t.root, a)
+	t.augment = a
+}
+
+// reaugment replaces the aggregates of the subtree h with those of a, copying
+// the nodes that the tree does not own, and returns its new root.
+func (t *Tree[T]) reaugment(h *TreeNode[T], a Augmenter[T]) *TreeNode[T] {
+	if h == nil {
+		return nil
+	}
+	h = t.mutable(h)
+	h.Left, h.Right = t.reaugment(h.Left, a), t.reaugment(h.Right, a)
+	h.aggregate = nil
+	if a != nil {
+		a.update(h)
+	}
+	return h
+}
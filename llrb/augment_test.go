@@ -0,0 +1,184 @@
+package llrb
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// sumAndHeight is an aggregate that depends on the shape of the subtree,
+// so it is only right if every changed node is updated.
+type sumAndHeight struct {
+	sum, height int
+}
+
+func newSumAndHeight() *Augment[int, sumAndHeight] {
+	return NewAugment(func(item int, left, right sumAndHeight) sumAndHeight {
+		return sumAndHeight{sum: left.sum + item + right.sum, height: 1 + max(left.height, right.height)}
+	})
+}
+
+// checkAggregates checks the aggregate of every node of tree.
+func checkAggregates(t *testing.T, tree *Tree[int], a *Augment[int, sumAndHeight]) {
+	t.Helper()
+	var check func(h *TreeNode[int]) sumAndHeight
+	check = func(h *TreeNode[int]) sumAndHeight {
+		if h == nil {
+			return sumAndHeight{}
+		}
+		l, r := check(h.Left), check(h.Right)
+		want := sumAndHeight{sum: l.sum + h.Item + r.sum, height: 1 + max(l.height, r.height)}
+		if got := a.Of(h); got != want {
+			t.Fatalf("aggregate of %v: %+v, expected %+v", h, got, want)
+		}
+		return want
+	}
+	check(tree.root)
+}
+
+func TestAugment(t *testing.T) {
+	for _, mode := range []Mode{Mode23, Mode234} {
+		a := newSumAndHeight()
+		tree := NewTree(lessInt, mode)
+		tree.SetAugment(a)
+		versions := []*Tree[int]{tree}
+		for k := 0; k < 2000; k++ {
+			tree := versions[rand.Intn(len(versions))].Clone()
+			key := rand.Intn(200)
+			switch rand.Intn(8) {
+			case 0, 1:
+				tree.InsertNoReplace(key)
+			case 2:
+				tree.ReplaceOrInsert(key)
+			case 3:
+				tree.Delete(key)
+			case 4:
+				tree.DeleteMin()
+			case 5:
+				tree.DeleteMax()
+			case 6:
+				tree.DeleteByRank(1 + rand.Intn(tree.Len()+1))
+			case 7:
+				tree = JoinTree(tree.Split(key))
+			}
+			checkAggregates(t, tree, a)
+			versions = append(versions, tree)
+		}
+		for _, v := range versions {
+			checkAggregates(t, v, a)
+		}
+	}
+}
+
+func TestAugment_Set(t *testing.T) {
+	tree := NewTree(lessInt)
+	for _, i := range rand.Perm(100) {
+		tree.InsertNoReplace(i)
+	}
+	old := tree.Clone()
+	a := newSumAndHeight()
+	tree.SetAugment(a)
+	checkAggregates(t, tree, a)
+	if got := a.Of(tree.Root()).sum; got != 99*100/2 {
+		t.Errorf("sum: %v", got)
+	}
+	if old.Root().aggregate != nil {
+		t.Error("SetAugment modified the nodes of a clone")
+	}
+
+	b := newSumAndHeight()
+	tree.SetAugment(b)
+	checkAggregates(t, tree, b)
+	tree.SetAugment(nil)
+	tree.InsertNoReplace(100)
+	tree.Ascend(func(int) bool { return true })
+	for h := tree.Root(); h != nil; h = h.Left {
+		if h.aggregate != nil {
+			t.Fatalf("%v still has an aggregate", h)
+		}
+	}
+}
+
+func TestAugment_Build(t *testing.T) {
+	a := newSumAndHeight()
+	tree := NewTree(lessInt)
+	tree.SetAugment(a)
+	items := make([]int, 300)
+	for i := range items {
+		items[i] = i
+	}
+	if err := tree.AppendSorted(items[:100]); err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.AppendSorted(items[100:]); err != nil {
+		t.Fatal(err)
+	}
+	checkAggregates(t, tree, a)
+
+	other := NewTree(lessInt)
+	other.SetAugment(a)
+	other.InsertNoReplaceBulk(5, 500, 1000)
+	for _, u := range []*Tree[int]{UnionTree(tree, other, nil), IntersectionTree(tree, other), DifferenceTree(tree, other)} {
+		checkAggregates(t, u, a)
+	}
+}
+
+func TestAugment_LLRB(t *testing.T) {
+	// counts the even Items of each subtree
+	evens := NewAugment(func(item Item, left, right int) int {
+		if item.(Int)%2 == 0 {
+			return left + 1 + right
+		}
+		return left + right
+	})
+	tree := New()
+	tree.SetAugment(evens)
+	tree.SetCodec(IntCodec{})
+	for _, i := range rand.Perm(101) {
+		tree.InsertNoReplace(Int(i))
+	}
+	if got := evens.Of(tree.Root()); got != 51 {
+		t.Errorf("evens: %v, expected 51", got)
+	}
+	data, err := tree.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := New()
+	decoded.SetAugment(evens)
+	decoded.SetCodec(IntCodec{})
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if got := evens.Of(decoded.Root()); got != 51 {
+		t.Errorf("evens after UnmarshalBinary: %v, expected 51", got)
+	}
+}
+
+func TestAugment_OfOtherTree(t *testing.T) {
+	sum := NewAugment(func(item int, left, right int) int { return left + item + right })
+	names := NewAugment(func(item int, left, right string) string { return left + "x" + right })
+
+	plain := NewTree(lessInt)
+	plain.AppendSorted([]int{1, 2, 3})
+	if got := sum.Of(plain.Root()); got != 0 {
+		t.Errorf("Of a tree without an Augment: %v, expected 0", got)
+	}
+
+	root := &TreeNode[int]{Item: 2, Black: true, NDescendants: 3}
+	root.Left = &TreeNode[int]{Item: 1, Black: true, NDescendants: 1}
+	root.Right = &TreeNode[int]{Item: 3, Black: true, NDescendants: 1}
+	plain.SetRoot(root)
+	if got := sum.Of(plain.Root()); got != 0 {
+		t.Errorf("Of a node installed with SetRoot: %v, expected 0", got)
+	}
+
+	weighted := NewTree(lessInt)
+	weighted.SetAugment(NewWeights(func(i int) int64 { return int64(i) }))
+	weighted.AppendSorted([]int{1, 2, 3})
+	if got := names.Of(weighted.Root()); got != "" {
+		t.Errorf("Of a node with an aggregate of another type: %q, expected empty", got)
+	}
+	if got := weighted.TotalWeight(); got != 6 {
+		t.Errorf("TotalWeight: %v, expected 6", got)
+	}
+}
@@ -0,0 +1,133 @@
+package llrb
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	tree, model := newTestTree(200)
+	versions := []*LLRB{tree}
+	models := [][]Int{model}
+	for k := 0; k < 500; k++ {
+		v := rand.Intn(len(versions))
+		tree, model := versions[v].Clone(), append([]Int(nil), models[v]...)
+		key := Int(rand.Intn(500))
+		i := sort.Search(len(model), func(i int) bool { return model[i] >= key })
+		switch rand.Intn(7) {
+		case 0:
+			tree.InsertNoReplace(key)
+			i = sort.Search(len(model), func(i int) bool { return model[i] > key })
+			model = append(model[:i], append([]Int{key}, model[i:]...)...)
+		case 1:
+			if tree.ReplaceOrInsert(key) == nil {
+				model = append(model[:i], append([]Int{key}, model[i:]...)...)
+			}
+		case 2:
+			if tree.Delete(key) != nil {
+				model = append(model[:i], model[i+1:]...)
+			}
+		case 3:
+			if tree.DeleteMin() != nil {
+				model = model[1:]
+			}
+		case 4:
+			if tree.DeleteMax() != nil {
+				model = model[:len(model)-1]
+			}
+		case 5:
+			if r := 1 + rand.Intn(len(model)+1); tree.DeleteByRank(r) != nil {
+				model = append(model[:r-1], model[r:]...)
+			}
+		case 6:
+			left, right := tree.Split(key)
+			tree = Join(left, right)
+		}
+		versions = append(versions, tree)
+		models = append(models, model)
+		for j := 0; j < 3; j++ {
+			v := rand.Intn(len(versions))
+			checkInvariants(t, versions[v], models[v])
+		}
+	}
+	for v := range versions {
+		checkInvariants(t, versions[v], models[v])
+	}
+}
+
+// intsFrom returns the Ints in [lo, hi).
+func intsFrom(lo, hi int) []Int {
+	var ints []Int
+	for i := lo; i < hi; i++ {
+		ints = append(ints, Int(i))
+	}
+	return ints
+}
+
+func TestClone_SplitJoin(t *testing.T) {
+	// the nodes of b, which was never cloned, end up in a joined tree
+	b := New()
+	for _, i := range intsFrom(100, 200) {
+		b.InsertNoReplace(i)
+	}
+	a := New()
+	a.InsertNoReplace(Int(1))
+	j := Join(a, b.Clone())
+	for i := 100; i < 150; i++ {
+		j.Delete(Int(i))
+	}
+	checkInvariants(t, b, intsFrom(100, 200))
+	checkInvariants(t, j, append([]Int{1}, intsFrom(150, 200)...))
+
+	// the right part of a split is cloned and joined back
+	x := New()
+	for _, i := range intsFrom(0, 200) {
+		x.InsertNoReplace(i)
+	}
+	l, r := x.Split(Int(100))
+	j = Join(l, r.Clone())
+	for i := 100; i < 150; i++ {
+		j.Delete(Int(i))
+	}
+	checkInvariants(t, r, intsFrom(100, 200))
+	checkInvariants(t, j, append(intsFrom(0, 100), intsFrom(150, 200)...))
+}
+
+func TestClone_Union(t *testing.T) {
+	x := New()
+	for _, i := range intsFrom(0, 100) {
+		x.InsertNoReplace(i)
+	}
+	u := Union(x, New(), nil)
+	snapshot := u.Clone()
+	empty, x := x.Split(Int(0))
+	j := Join(empty, u)
+	for i := 0; i < 50; i++ {
+		j.Delete(Int(i))
+	}
+	checkInvariants(t, snapshot, intsFrom(0, 100))
+	checkInvariants(t, j, intsFrom(50, 100))
+}
+
+func TestClone_PathCopying(t *testing.T) {
+	tree, _ := newTestTree(1000)
+	next := tree.Clone()
+	next.ReplaceOrInsert(Int(-1))
+	next.Delete(Int(-1))
+	next.InsertNoReplace(Int(3000))
+	nodes := map[*Node]bool{}
+	var walk func(h *Node)
+	walk = func(h *Node) {
+		if h != nil {
+			nodes[h] = true
+			walk(h.Left)
+			walk(h.Right)
+		}
+	}
+	walk(tree.root)
+	walk(next.root)
+	if n := len(nodes) - tree.Len(); n > 100 {
+		t.Errorf("3 operations on a clone copied %v nodes", n)
+	}
+}
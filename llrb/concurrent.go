@@ -0,0 +1,157 @@
+package llrb
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// ConcurrentLLRB is an LLRB that is safe for concurrent use.
+// Writers are serialized with a mutex and modify a private version of the tree,
+// then publish a Clone of it, which costs O(1) and makes the next write copy
+// the paths it changes. Readers never lock: each read runs on the latest
+// published version, so a long walk sees a consistent snapshot of the tree
+// and does not block writers or other readers.
+type ConcurrentLLRB struct {
+	mu       sync.Mutex // serializes writers
+	work     *LLRB      // the version modified by writers, guarded by mu
+	snapshot atomic.Pointer[LLRB]
+}
+
+// NewConcurrent allocates a new empty ConcurrentLLRB.
+func NewConcurrent() *ConcurrentLLRB {
+	t := &ConcurrentLLRB{work: New()}
+	t.snapshot.Store(t.work.Clone())
+	return t
+}
+
+// Snapshot returns the latest published version of the tree, which is never
+// modified afterwards. Any number of goroutines can read it, it can be used for
+// the read methods of LLRB that ConcurrentLLRB does not have, or to make several
+// reads on the same version. It must not be modified, modify a Clone of it instead:
+// cloning it does not modify it, so any number of goroutines can clone it.
+func (t *ConcurrentLLRB) Snapshot() *LLRB {
+	return t.snapshot.Load()
+}
+
+// Update calls fn with the tree and publishes the changes that fn made to it at once,
+// readers see either none or all of them. Writers are blocked while fn runs,
+// fn must not keep the tree after returning.
+func (t *ConcurrentLLRB) Update(fn func(tree *LLRB)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fn(t.work)
+	t.snapshot.Store(t.work.Clone())
+}
+
+// ReplaceOrInsert inserts item into the tree. If an existing
+// element has the same order, it is removed from the tree and returned.
+func (t *ConcurrentLLRB) ReplaceOrInsert(item Item) (replaced Item) {
+	t.Update(func(tree *LLRB) { replaced = tree.ReplaceOrInsert(item) })
+	return replaced
+}
+
+// InsertNoReplace inserts item into the tree. If an existing
+// element has the same order, both elements remain in the tree.
+func (t *ConcurrentLLRB) InsertNoReplace(item Item) {
+	t.Update(func(tree *LLRB) { tree.InsertNoReplace(item) })
+}
+
+// ReplaceOrInsertBulk is ReplaceOrInsert for each of items, they are published at once.
+func (t *ConcurrentLLRB) ReplaceOrInsertBulk(items ...Item) {
+	t.Update(func(tree *LLRB) { tree.ReplaceOrInsertBulk(items...) })
+}
+
+// InsertNoReplaceBulk is InsertNoReplace for each of items, they are published at once.
+func (t *ConcurrentLLRB) InsertNoReplaceBulk(items ...Item) {
+	t.Update(func(tree *LLRB) { tree.InsertNoReplaceBulk(items...) })
+}
+
+// Delete deletes an item from the tree whose key equals key.
+// The deleted item is returned, otherwise nil is returned.
+func (t *ConcurrentLLRB) Delete(key Item) (deleted Item) {
+	t.Update(func(tree *LLRB) { deleted = tree.Delete(key) })
+	return deleted
+}
+
+// DeleteMin deletes the minimum element in the tree and returns the
+// deleted item or nil otherwise.
+func (t *ConcurrentLLRB) DeleteMin() (deleted Item) {
+	t.Update(func(tree *LLRB) { deleted = tree.DeleteMin() })
+	return deleted
+}
+
+// DeleteMax deletes the maximum element in the tree and returns
+// the deleted item or nil otherwise.
+func (t *ConcurrentLLRB) DeleteMax() (deleted Item) {
+	t.Update(func(tree *LLRB) { deleted = tree.DeleteMax() })
+	return deleted
+}
+
+// DeleteByRank deletes the item with rank r (rank start from 1) from the tree
+// and returns it, nil is returned if r is not in [1, t.Len()].
+func (t *ConcurrentLLRB) DeleteByRank(r int) (deleted Item) {
+	t.Update(func(tree *LLRB) { deleted = tree.DeleteByRank(r) })
+	return deleted
+}
+
+// Len returns the number of nodes in the tree.
+func (t *ConcurrentLLRB) Len() int { return t.Snapshot().Len() }
+
+// Has returns true if the tree contains an element whose order is the same as that of key.
+func (t *ConcurrentLLRB) Has(key Item) bool { return t.Snapshot().Has(key) }
+
+// Get retrieves an element from the tree whose order is the same as that of key.
+func (t *ConcurrentLLRB) Get(key Item) Item { return t.Snapshot().Get(key) }
+
+// Min returns the minimum element in the tree.
+func (t *ConcurrentLLRB) Min() Item { return t.Snapshot().Min() }
+
+// Max returns the maximum element in the tree.
+func (t *ConcurrentLLRB) Max() Item { return t.Snapshot().Max() }
+
+// GetByRank retrieves the item with a given rank r (rank start from 1), see LLRB.GetByRank.
+func (t *ConcurrentLLRB) GetByRank(r int) Item { return t.Snapshot().GetByRank(r) }
+
+// GetRankOf determines rank of an key (rank start from 1), see LLRB.GetRankOf.
+func (t *ConcurrentLLRB) GetRankOf(key Item) (int, Item) { return t.Snapshot().GetRankOf(key) }
+
+// Ascend will call iterator once for each element in ascending order.
+func (t *ConcurrentLLRB) Ascend(iterator ItemIterator) {
+	t.Snapshot().Ascend(iterator)
+}
+
+// AscendRange will call iterator once for each element greater or equal to greaterOrEqual
+// and less than lessThan in ascending order. It will stop whenever the iterator returns false.
+func (t *ConcurrentLLRB) AscendRange(greaterOrEqual, lessThan Item, iterator ItemIterator) {
+	t.Snapshot().AscendRange(greaterOrEqual, lessThan, iterator)
+}
+
+// AscendGreaterOrEqual will call iterator once for each element greater or equal to
+// pivot in ascending order. It will stop whenever the iterator returns false.
+func (t *ConcurrentLLRB) AscendGreaterOrEqual(pivot Item, iterator ItemIterator) {
+	t.Snapshot().AscendGreaterOrEqual(pivot, iterator)
+}
+
+// AscendGreaterThan will call iterator once for each element greater than
+// pivot in ascending order. It will stop whenever the iterator returns false.
+func (t *ConcurrentLLRB) AscendGreaterThan(pivot Item, iterator ItemIterator) {
+	t.Snapshot().AscendGreaterThan(pivot, iterator)
+}
+
+// AscendLessThan will call iterator once for each element lower than
+// pivot in ascending order. It will stop whenever the iterator returns false.
+func (t *ConcurrentLLRB) AscendLessThan(pivot Item, iterator ItemIterator) {
+	t.Snapshot().AscendLessThan(pivot, iterator)
+}
+
+// AscendLessOrEqual will call iterator once for each element lower or equal to
+// pivot in ascending order. It will stop whenever the iterator returns false.
+func (t *ConcurrentLLRB) AscendLessOrEqual(pivot Item, iterator ItemIterator) {
+	t.Snapshot().AscendLessOrEqual(pivot, iterator)
+}
+
+// AscendRankRange will call iterator once for each element with rank in [from, to)
+// in ascending order (rank start from 1). It will stop whenever the iterator returns false.
+func (t *ConcurrentLLRB) AscendRankRange(from, to int, iterator ItemIterator) {
+	t.Snapshot().AscendRankRange(from, to, iterator)
+}
@@ -0,0 +1,131 @@
+package llrb
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentLLRB(t *testing.T) {
+	tree := NewConcurrent()
+	const writers, n = 4, 300
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			// writer w owns the keys equal to w modulo writers, it inserts and deletes
+			// the pairs 2k, 2k+1 at once so that readers always see both or none
+			for i := 0; i < n; i++ {
+				k := Int(2 * (writers*rand.Intn(n/writers) + w))
+				if tree.Get(k) == nil {
+					tree.Update(func(t *LLRB) { t.InsertNoReplaceBulk(k, k+1) })
+				} else {
+					tree.Update(func(t *LLRB) { t.Delete(k); t.Delete(k + 1) })
+				}
+			}
+		}(w)
+	}
+	var readers sync.WaitGroup
+	for r := 0; r < 4; r++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				var items []Item
+				tree.Ascend(func(i Item) bool {
+					items = append(items, i)
+					return true
+				})
+				if len(items)%2 != 0 {
+					t.Errorf("a walk saw %v items", len(items))
+					return
+				}
+				for j := 0; j < len(items); j += 2 {
+					if items[j].(Int)%2 != 0 || items[j+1] != items[j].(Int)+1 {
+						t.Errorf("a walk saw %v then %v", items[j], items[j+1])
+						return
+					}
+				}
+				snapshot := tree.Snapshot()
+				if r := 1 + rand.Intn(snapshot.Len()+1); r <= snapshot.Len() {
+					item := snapshot.GetByRank(r)
+					if rank, found := snapshot.GetRankOf(item); rank != r || found != item {
+						t.Errorf("GetRankOf(GetByRank(%v)) = %v, %v", r, rank, found)
+						return
+					}
+				}
+				tree.AscendRange(Int(n), Int(2*n), func(i Item) bool { return i.(Int) < Int(2*n) })
+				tree.GetByRank(rand.Intn(n))
+				tree.GetRankOf(Int(rand.Intn(2 * n)))
+			}
+		}()
+	}
+	wg.Wait()
+	close(done)
+	readers.Wait()
+
+	var model []Int
+	tree.Ascend(func(i Item) bool {
+		model = append(model, i.(Int))
+		return true
+	})
+	checkInvariants(t, tree.Snapshot(), model)
+	if tree.Len() != len(model) {
+		t.Errorf("expected len %v, reality %v", len(model), tree.Len())
+	}
+}
+
+func TestConcurrentLLRB_Snapshot(t *testing.T) {
+	tree := NewConcurrent()
+	tree.InsertNoReplaceBulk(Int(1), Int(2), Int(3))
+	snapshot := tree.Snapshot()
+	tree.ReplaceOrInsert(Int(4))
+	tree.Delete(Int(1))
+	tree.DeleteMin()
+	tree.DeleteByRank(2)
+	checkInvariants(t, snapshot, []Int{1, 2, 3})
+	checkInvariants(t, tree.Snapshot(), []Int{3})
+	if tree.DeleteMax() != Int(3) || tree.Len() != 0 || snapshot.Len() != 3 {
+		t.Error("unexpected DeleteMax")
+	}
+}
+
+func TestConcurrentLLRB_CloneSnapshot(t *testing.T) {
+	tree := NewConcurrent()
+	for _, i := range intsFrom(0, 100) {
+		tree.InsertNoReplace(i)
+	}
+	// goroutine g clones the snapshots into clones[g] and inserts 1000+g into them
+	const goroutines, n = 4, 50
+	var snapshots, clones [goroutines][n]*LLRB
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for k := 0; k < n; k++ {
+				snapshots[g][k] = tree.Snapshot()
+				clones[g][k] = snapshots[g][k].Clone()
+				clones[g][k].InsertNoReplace(Int(1000 + g))
+			}
+		}(g)
+	}
+	for i := 100; i < 150; i++ {
+		tree.InsertNoReplace(Int(i))
+	}
+	wg.Wait()
+	for g := range clones {
+		for k, clone := range clones[g] {
+			model := intsFrom(0, snapshots[g][k].Len())
+			checkInvariants(t, snapshots[g][k], model)
+			checkInvariants(t, clone, append(model, Int(1000+g)))
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package llrb
+
+// Cursor is a position in a tree that can be moved in both directions.
+// It keeps the path from the root to the current node,
+// so a cursor must not be used after the tree has been modified.
+type Cursor[T any] struct {
+	t    *Tree[T]
+	path []*TreeNode[T] // nodes from the root to the current node
+	rank int            // rank of the current node, 0 if the cursor is not valid
+}
+
+// Seek returns a cursor positioned at the first element greater or equal to key,
+// the cursor is not valid if there is no such element.
+func (t *Tree[T]) Seek(key T) *Cursor[T] {
+	c := &Cursor[T]{t: t}
+	c.Seek(key)
+	return c
+}
+
+// SeekRank returns a cursor positioned at the element with rank r (rank start from 1),
+// the cursor is not valid if r is not in [1, t.Len()].
+func (t *Tree[T]) SeekRank(r int) *Cursor[T] {
+	c := &Cursor[T]{t: t}
+	c.SeekRank(r)
+	return c
+}
+
+// Valid reports whether the cursor is positioned at an element.
+func (c *Cursor[T]) Valid() bool { return c.rank > 0 }
+
+// Item returns the element at the cursor, or the zero value if the cursor is not valid.
+func (c *Cursor[T]) Item() T {
+	if !c.Valid() {
+		var zero T
+		return zero
+	}
+	return c.path[len(c.path)-1].Item
+}
+
+// Rank returns the rank of the element at the cursor (rank start from 1),
+// or 0 if the cursor is not valid.
+func (c *Cursor[T]) Rank() int { return c.rank }
+
+func (c *Cursor[T]) invalidate() {
+	c.path = c.path[:0]
+	c.rank = 0
+}
+
+// Seek moves the cursor to the first element greater or equal to key
+// and reports whether the cursor is valid.
+func (c *Cursor[T]) Seek(key T) bool {
+	c.invalidate()
+	found, nLess := 0, 0 // found is len(c.path) when the candidate was pushed
+	for h := c.t.root; h != nil; {
+		c.path = append(c.path, h)
+		if c.t.less(h.Item, key) {
+			nLess += size(h.Left) + 1
+			h = h.Right
+		} else {
+			found, c.rank = len(c.path), nLess+size(h.Left)+1
+			h = h.Left
+		}
+	}
+	c.path = c.path[:found]
+	return c.Valid()
+}
+
+// SeekRank moves the cursor to the element with rank r, in O(log n),
+// and reports whether the cursor is valid.
+func (c *Cursor[T]) SeekRank(r int) bool {
+	c.invalidate()
+	if r < 1 || r > size(c.t.root) {
+		return false
+	}
+	c.rank = r
+	for h := c.t.root; h != nil; {
+		c.path = append(c.path, h)
+		hRank := size(h.Left) + 1
+		switch {
+		case r == hRank:
+			return true
+		case r < hRank:
+			h = h.Left
+		default:
+			r -= hRank
+			h = h.Right
+		}
+	}
+	panic("logic") // NDescendants does not match the tree
+}
+
+// First moves the cursor to the minimum element and reports whether the cursor is valid.
+func (c *Cursor[T]) First() bool { return c.SeekRank(1) }
+
+// Last moves the cursor to the maximum element and reports whether the cursor is valid.
+func (c *Cursor[T]) Last() bool { return c.SeekRank(size(c.t.root)) }
+
+// Next moves the cursor to the next element in ascending order
+// and reports whether the cursor is still valid.
+// Once the cursor has moved past the maximum element, Next and Prev do nothing.
+func (c *Cursor[T]) Next() bool {
+	if !c.Valid() {
+		return false
+	}
+	h := c.path[len(c.path)-1]
+	if h.Right != nil {
+		for h = h.Right; h != nil; h = h.Left {
+			c.path = append(c.path, h)
+		}
+		c.rank++
+		return true
+	}
+	for len(c.path) > 1 {
+		child := c.path[len(c.path)-1]
+		c.path = c.path[:len(c.path)-1]
+		if c.path[len(c.path)-1].Left == child {
+			c.rank++
+			return true
+		}
+	}
+	c.invalidate()
+	return false
+}
+
+// Prev moves the cursor to the previous element in ascending order
+// and reports whether the cursor is still valid.
+// Once the cursor has moved before the minimum element, Next and Prev do nothing.
+func (c *Cursor[T]) Prev() bool {
+	if !c.Valid() {
+		return false
+	}
+	h := c.path[len(c.path)-1]
+	if h.Left != nil {
+		for h = h.Left; h != nil; h = h.Right {
+			c.path = append(c.path, h)
+		}
+		c.rank--
+		return true
+	}
+	for len(c.path) > 1 {
+		child := c.path[len(c.path)-1]
+		c.path = c.path[:len(c.path)-1]
+		if c.path[len(c.path)-1].Right == child {
+			c.rank--
+			return true
+		}
+	}
+	c.invalidate()
+	return false
+}
@@ -0,0 +1,99 @@
+package llrb
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// newTestTree returns an LLRB holding n random Ints in [0, 2n) with duplicates,
+// together with the sorted slice of the same Ints as a reference model.
+func newTestTree(n int) (*LLRB, []Int) {
+	tree := New()
+	var model []Int
+	for i := 0; i < n; i++ {
+		e := Int(rand.Intn(2 * n))
+		tree.InsertNoReplace(e)
+		model = append(model, e)
+	}
+	sort.Slice(model, func(i, j int) bool { return model[i] < model[j] })
+	return tree, model
+}
+
+func TestCursor_Seek(t *testing.T) {
+	tree, model := newTestTree(200)
+	for key := Int(-1); key <= 401; key++ {
+		c := tree.Seek(key)
+		i := sort.Search(len(model), func(i int) bool { return model[i] >= key })
+		if i == len(model) {
+			if c.Valid() || c.Item() != nil || c.Rank() != 0 {
+				t.Fatalf("Seek(%v): expected an invalid cursor, reality: %v", key, c.Item())
+			}
+			continue
+		}
+		for ; i < len(model); i++ {
+			if !c.Valid() || c.Item() != model[i] || c.Rank() != i+1 {
+				t.Fatalf("Seek(%v): expected %v at rank %v, reality: %v at rank %v",
+					key, model[i], i+1, c.Item(), c.Rank())
+			}
+			c.Next()
+		}
+		if c.Valid() || c.Next() || c.Prev() {
+			t.Fatalf("Seek(%v): cursor must be invalid past the maximum", key)
+		}
+	}
+}
+
+func TestCursor_Prev(t *testing.T) {
+	tree, model := newTestTree(200)
+	c := tree.Seek(Int(1000))
+	if c.Valid() {
+		t.Fatal("Seek past the maximum must be invalid")
+	}
+	if !c.Last() {
+		t.Fatal("Last on a non-empty tree must be valid")
+	}
+	for i := len(model) - 1; i >= 0; i-- {
+		if !c.Valid() || c.Item() != model[i] || c.Rank() != i+1 {
+			t.Fatalf("expected %v at rank %v, reality: %v at rank %v",
+				model[i], i+1, c.Item(), c.Rank())
+		}
+		c.Prev()
+	}
+	if c.Valid() {
+		t.Fatal("cursor must be invalid before the minimum")
+	}
+}
+
+func TestCursor_SeekRank(t *testing.T) {
+	tree, model := newTestTree(300)
+	c := tree.SeekRank(0)
+	if c.Valid() || c.SeekRank(len(model)+1) {
+		t.Fatal("out of range ranks must be invalid")
+	}
+	for i := 0; i < 1000; i++ {
+		r := 1 + rand.Intn(len(model))
+		if !c.SeekRank(r) || c.Item() != model[r-1] || c.Rank() != r {
+			t.Fatalf("SeekRank(%v): expected %v, reality: %v", r, model[r-1], c.Item())
+		}
+		// walk a few steps forward, without falling off the end, and come back
+		steps := rand.Intn(10)
+		for s := 0; s < steps && c.Rank() < len(model); s++ {
+			c.Next()
+		}
+		for c.Valid() && c.Rank() > r {
+			c.Prev()
+		}
+		if !c.Valid() || c.Rank() != r || c.Item() != model[r-1] {
+			t.Fatalf("SeekRank(%v): lost position after Next/Prev", r)
+		}
+	}
+}
+
+func TestCursor_Empty(t *testing.T) {
+	tree := New()
+	c := tree.Seek(Int(1))
+	if c.Valid() || c.First() || c.Last() || c.Next() || c.Prev() {
+		t.Error("cursor over an empty tree must be invalid")
+	}
+}
@@ -0,0 +1,99 @@
+package llrb
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteDOT writes the tree to w in the Graphviz DOT language, for debugging, e.g.
+//
+//	dot -Tsvg tree.dot > tree.svg
+//
+// Each node is labeled with its element, its NDescendants and its black height
+// (the number of black nodes on a path from it down to a leaf, itself included).
+// Red links are drawn in red, a missing child whose sibling exists is drawn as a point
+// so that left and right children can be told apart.
+func (t *Tree[T]) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("digraph llrb {\n\tnode [shape=box, style=rounded];\n")
+	id := 0
+	var write func(h *TreeNode[T]) (name string, bh int)
+	write = func(h *TreeNode[T]) (string, int) {
+		name := "n" + strconv.Itoa(id)
+		id++
+		if h == nil {
+			fmt.Fprintf(bw, "\t%s [shape=point];\n", name)
+			return name, 0
+		}
+		var left, right string
+		var bh int
+		if h.Left != nil || h.Right != nil {
+			left, bh = write(h.Left)
+			right, _ = write(h.Right)
+		}
+		if h.Black {
+			bh++
+		}
+		label := fmt.Sprintf("%v\nsize %d, bh %d", h.Item, h.NDescendants, bh)
+		fmt.Fprintf(bw, "\t%s [label=%s];\n", name, strconv.Quote(label))
+		for _, c := range []struct {
+			name string
+			node *TreeNode[T]
+		}{{left, h.Left}, {right, h.Right}} {
+			if c.name == "" {
+				continue
+			}
+			if isRed(c.node) {
+				fmt.Fprintf(bw, "\t%s -> %s [color=red, penwidth=2];\n", name, c.name)
+			} else {
+				fmt.Fprintf(bw, "\t%s -> %s;\n", name, c.name)
+			}
+		}
+		return name, bh
+	}
+	if t.root != nil {
+		write(t.root)
+	}
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+// Pretty draws the tree sideways for a terminal, for debugging: the root is on the left,
+// the right subtree above it and the left subtree below it. Each node is shown with
+// its NDescendants in parentheses, a red link is drawn with double lines, e.g.
+//
+//	┌── 6 (2)
+//	│   └══ 5 (1)
+//	4 (6)
+//	│   ┌── 3 (1)
+//	└══ 2 (3)
+//	    └── 1 (1)
+func (t *Tree[T]) Pretty() string {
+	var b strings.Builder
+	if t.root != nil {
+		pretty(&b, t.root, "", "", "")
+	}
+	return b.String()
+}
+
+// pretty draws the subtree h, above, line and below are the prefixes of the lines
+// of its right subtree, its own line and the lines of its left subtree.
+func pretty[T any](b *strings.Builder, h *TreeNode[T], above, line, below string) {
+	if h.Right != nil {
+		pretty(b, h.Right, above+"    ", above+prettyLink("┌", h.Right), above+"│   ")
+	}
+	fmt.Fprintf(b, "%s%v (%d)\n", line, h.Item, h.NDescendants)
+	if h.Left != nil {
+		pretty(b, h.Left, below+"│   ", below+prettyLink("└", h.Left), below+"    ")
+	}
+}
+
+func prettyLink[T any](corner string, child *TreeNode[T]) string {
+	if isRed(child) {
+		return corner + "══ "
+	}
+	return corner + "── "
+}
@@ -0,0 +1,47 @@
+package llrb
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPretty(t *testing.T) {
+	tree := New()
+	if tree.Pretty() != "" {
+		t.Errorf("an empty tree must be drawn as an empty string")
+	}
+	tree.InsertNoReplaceBulk(Int(1), Int(2), Int(3), Int(4), Int(5), Int(6))
+	expected := `┌── 6 (2)
+│   └══ 5 (1)
+4 (6)
+│   ┌── 3 (1)
+└══ 2 (3)
+    └── 1 (1)
+`
+	if s := tree.Pretty(); s != expected {
+		t.Errorf("expected:\n%v\nreality:\n%v", expected, s)
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	tree := New()
+	tree.InsertNoReplaceBulk(Int(1), Int(2), Int(3), Int(4), Int(5), Int(6))
+	var b strings.Builder
+	if err := tree.WriteDOT(&b); err != nil {
+		t.Fatal(err)
+	}
+	s := b.String()
+	for _, line := range []string{
+		"digraph llrb {",
+		`n0 [label="4\nsize 6, bh 2"];`,
+		`n4 [label="6\nsize 2, bh 1"];`,
+		`n5 [label="5\nsize 1, bh 0"];`,
+		"n6 [shape=point];",
+		"n4 -> n5 [color=red, penwidth=2];",
+		"n4 -> n6;",
+	} {
+		if !strings.Contains(s, line) {
+			t.Errorf("%q not in:\n%v", line, s)
+		}
+	}
+}
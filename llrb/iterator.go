@@ -1,95 +1,232 @@
 package llrb
 
+// ItemIterator is called for each item visited by an LLRB walk,
+// the walk stops when it returns false.
 type ItemIterator func(i Item) bool
 
-//func (t *Tree) Ascend(iterator ItemIterator) {
-//	t.AscendGreaterOrEqual(Inf(-1), iterator)
-//}
+// Ascend will call iterator once for each element in ascending order.
+// It will stop whenever the iterator returns false.
+func (t *Tree[T]) Ascend(iterator func(i T) bool) {
+	t.ascend(bound[T]{}, bound[T]{}, unrankedIterator(iterator))
+}
+
+// AscendRange will call iterator once for each element greater or equal to greaterOrEqual
+// and less than lessThan in ascending order. It will stop whenever the iterator returns false.
+func (t *Tree[T]) AscendRange(greaterOrEqual, lessThan T, iterator func(i T) bool) {
+	t.ascend(inclusive(greaterOrEqual), exclusive(lessThan), unrankedIterator(iterator))
+}
+
+// AscendGreaterOrEqual will call iterator once for each element greater or equal to
+// pivot in ascending order. It will stop whenever the iterator returns false.
+func (t *Tree[T]) AscendGreaterOrEqual(pivot T, iterator func(i T) bool) {
+	t.ascend(inclusive(pivot), bound[T]{}, unrankedIterator(iterator))
+}
+
+// AscendGreaterThan will call iterator once for each element greater than
+// pivot in ascending order. It will stop whenever the iterator returns false.
+func (t *Tree[T]) AscendGreaterThan(pivot T, iterator func(i T) bool) {
+	t.ascend(exclusive(pivot), bound[T]{}, unrankedIterator(iterator))
+}
+
+// AscendLessThan will call iterator once for each element lower than
+// pivot in ascending order. It will stop whenever the iterator returns false.
+func (t *Tree[T]) AscendLessThan(pivot T, iterator func(i T) bool) {
+	t.ascend(bound[T]{}, exclusive(pivot), unrankedIterator(iterator))
+}
+
+// AscendLessOrEqual will call iterator once for each element lower or equal to
+// pivot in ascending order. It will stop whenever the iterator returns false.
+func (t *Tree[T]) AscendLessOrEqual(pivot T, iterator func(i T) bool) {
+	t.ascend(bound[T]{}, inclusive(pivot), unrankedIterator(iterator))
+}
+
+// Descend will call iterator once for each element in descending order.
+// It will stop whenever the iterator returns false.
+func (t *Tree[T]) Descend(iterator func(i T) bool) {
+	t.descend(bound[T]{}, bound[T]{}, unrankedIterator(iterator))
+}
+
+// DescendRange will call iterator once for each element less or equal to lessOrEqual
+// and greater than greaterThan in descending order. It will stop whenever the iterator returns false.
+func (t *Tree[T]) DescendRange(lessOrEqual, greaterThan T, iterator func(i T) bool) {
+	t.descend(exclusive(greaterThan), inclusive(lessOrEqual), unrankedIterator(iterator))
+}
+
+// DescendLessOrEqual will call iterator once for each element less or equal to
+// pivot in descending order. It will stop whenever the iterator returns false.
+func (t *Tree[T]) DescendLessOrEqual(pivot T, iterator func(i T) bool) {
+	t.descend(bound[T]{}, inclusive(pivot), unrankedIterator(iterator))
+}
+
+// DescendLessThan will call iterator once for each element less than
+// pivot in descending order. It will stop whenever the iterator returns false.
+func (t *Tree[T]) DescendLessThan(pivot T, iterator func(i T) bool) {
+	t.descend(bound[T]{}, exclusive(pivot), unrankedIterator(iterator))
+}
 
-func (t *LLRB) AscendRange(greaterOrEqual, lessThan Item, iterator ItemIterator) {
-	t.ascendRange(t.root, greaterOrEqual, lessThan, iterator)
+// DescendGreaterThan will call iterator once for each element greater than
+// pivot in descending order. It will stop whenever the iterator returns false.
+func (t *Tree[T]) DescendGreaterThan(pivot T, iterator func(i T) bool) {
+	t.descend(exclusive(pivot), bound[T]{}, unrankedIterator(iterator))
 }
 
-func (t *LLRB) ascendRange(h *Node, inf, sup Item, iterator ItemIterator) bool {
-	if h == nil {
+// DescendGreaterOrEqual will call iterator once for each element greater or equal to
+// pivot in descending order. It will stop whenever the iterator returns false.
+func (t *Tree[T]) DescendGreaterOrEqual(pivot T, iterator func(i T) bool) {
+	t.descend(inclusive(pivot), bound[T]{}, unrankedIterator(iterator))
+}
+
+// AscendRankRange will call iterator once for each element with rank in [from, to)
+// in ascending order (rank start from 1). It will stop whenever the iterator returns false.
+// The first element is found in O(log n) using NDescendants, so visiting k elements costs O(log n + k).
+func (t *Tree[T]) AscendRankRange(from, to int, iterator func(i T) bool) {
+	t.ascendRank(from, to, unrankedIterator(iterator))
+}
+
+// DescendRankRange will call iterator once for each element with rank in (to, from]
+// in descending order, that is from rank from down to rank to+1.
+// It will stop whenever the iterator returns false.
+func (t *Tree[T]) DescendRankRange(from, to int, iterator func(i T) bool) {
+	t.descendRank(from, to, unrankedIterator(iterator))
+}
+
+// unrankedIterator adapts iterator to the ranked walks below.
+func unrankedIterator[T any](iterator func(i T) bool) func(r int, i T) bool {
+	return func(_ int, i T) bool { return iterator(i) }
+}
+
+// bound is a limit of a walk, a walk is not limited on the side of an unset bound.
+type bound[T any] struct {
+	key       T
+	set       bool
+	inclusive bool
+}
+
+func inclusive[T any](key T) bound[T] { return bound[T]{key: key, set: true, inclusive: true} }
+
+func exclusive[T any](key T) bound[T] { return bound[T]{key: key, set: true} }
+
+// above reports whether item is on the inner side of the lower bound lo.
+func (t *Tree[T]) above(item T, lo bound[T]) bool {
+	if !lo.set {
 		return true
 	}
-	if !less(h.Item, sup) {
-		return t.ascendRange(h.Left, inf, sup, iterator)
-	}
-	if less(h.Item, inf) {
-		return t.ascendRange(h.Right, inf, sup, iterator)
+	if lo.inclusive {
+		return !t.less(item, lo.key)
 	}
+	return t.less(lo.key, item)
+}
 
-	if !t.ascendRange(h.Left, inf, sup, iterator) {
-		return false
+// below reports whether item is on the inner side of the upper bound hi.
+func (t *Tree[T]) below(item T, hi bound[T]) bool {
+	if !hi.set {
+		return true
 	}
-	if !iterator(h.Item) {
-		return false
+	if hi.inclusive {
+		return !t.less(hi.key, item)
 	}
-	return t.ascendRange(h.Right, inf, sup, iterator)
+	return t.less(item, hi.key)
 }
 
-// AscendGreaterOrEqual will call iterator once for each element greater or equal to
-// pivot in ascending order. It will stop whenever the iterator returns false.
-func (t *LLRB) AscendGreaterOrEqual(pivot Item, iterator ItemIterator) {
-	t.ascendGreaterOrEqual(t.root, pivot, iterator)
+// The walks below are iterative: a first descent stacks the path to the first
+// element to visit, then each element is popped and the path to the next one is
+// stacked. The stack is an array of maxHeight nodes, so a walk does not allocate.
+
+// ascend calls iterator with the rank and the item of each element between lo and hi
+// in ascending order.
+func (t *Tree[T]) ascend(lo, hi bound[T], iterator func(r int, i T) bool) bool {
+	return t.ascendFrom(
+		func(h *TreeNode[T], _ int) bool { return !t.above(h.Item, lo) },
+		func(h *TreeNode[T], _ int) bool { return !t.below(h.Item, hi) },
+		iterator)
 }
 
-func (t *LLRB) ascendGreaterOrEqual(h *Node, pivot Item, iterator ItemIterator) bool {
-	if h == nil {
-		return true
-	}
-	if !less(h.Item, pivot) {
-		if !t.ascendGreaterOrEqual(h.Left, pivot, iterator) {
-			return false
-		}
-		if !iterator(h.Item) {
-			return false
-		}
-	}
-	return t.ascendGreaterOrEqual(h.Right, pivot, iterator)
+// descend is ascend in descending order.
+func (t *Tree[T]) descend(lo, hi bound[T], iterator func(r int, i T) bool) bool {
+	return t.descendFrom(
+		func(h *TreeNode[T], _ int) bool { return !t.below(h.Item, hi) },
+		func(h *TreeNode[T], _ int) bool { return !t.above(h.Item, lo) },
+		iterator)
 }
 
-// AscendLessThan will call iterator once for each element lower than
-// pivot in ascending order. It will stop whenever the iterator returns false.
-func (t *LLRB) AscendLessThan(pivot Item, iterator ItemIterator) {
-	t.ascendLessThan(t.root, pivot, iterator)
+// ascendRank calls iterator with the rank and the item of each element
+// with rank in [from, to) in ascending order.
+func (t *Tree[T]) ascendRank(from, to int, iterator func(r int, i T) bool) bool {
+	return t.ascendFrom(
+		func(_ *TreeNode[T], rank int) bool { return rank < from },
+		func(_ *TreeNode[T], rank int) bool { return rank >= to },
+		iterator)
 }
 
-func (t *LLRB) ascendLessThan(h *Node, pivot Item, iterator ItemIterator) bool {
-	if h == nil {
-		return true
-	}
-	if !t.ascendLessThan(h.Left, pivot, iterator) {
-		return false
+// descendRank calls iterator with the rank and the item of each element
+// with rank in (to, from] in descending order.
+func (t *Tree[T]) descendRank(from, to int, iterator func(r int, i T) bool) bool {
+	return t.descendFrom(
+		func(_ *TreeNode[T], rank int) bool { return rank > from },
+		func(_ *TreeNode[T], rank int) bool { return rank <= to },
+		iterator)
+}
+
+// ascendFrom calls iterator with the rank and the item of each element in ascending order,
+// skipping the elements for which skip holds, which must be a prefix of them,
+// until stop holds or iterator returns false. It returns false if iterator did.
+func (t *Tree[T]) ascendFrom(skip, stop func(h *TreeNode[T], rank int) bool, iterator func(r int, i T) bool) bool {
+	var buf [maxHeight]*TreeNode[T]
+	path := buf[:0]
+	off := 0 // the number of elements before the top of path
+	for h := t.root; h != nil; {
+		if r := off + size(h.Left) + 1; skip(h, r) {
+			off = r
+			h = h.Right
+		} else {
+			path = append(path, h)
+			h = h.Left
+		}
 	}
-	if less(h.Item, pivot) {
-		if !iterator(h.Item) {
+	for len(path) > 0 {
+		h := path[len(path)-1]
+		path = path[:len(path)-1]
+		off++
+		if stop(h, off) {
+			return true
+		}
+		if !iterator(off, h.Item) {
 			return false
 		}
-		return t.ascendLessThan(h.Right, pivot, iterator)
+		for h = h.Right; h != nil; h = h.Left {
+			path = append(path, h)
+		}
 	}
 	return true
 }
 
-// DescendLessOrEqual will call iterator once for each element less than the
-// pivot in descending order. It will stop whenever the iterator returns false.
-func (t *LLRB) DescendLessOrEqual(pivot Item, iterator ItemIterator) {
-	t.descendLessOrEqual(t.root, pivot, iterator)
-}
-
-func (t *LLRB) descendLessOrEqual(h *Node, pivot Item, iterator ItemIterator) bool {
-	if h == nil {
-		return true
+// descendFrom is ascendFrom in descending order, the elements for which skip holds
+// must be a suffix of them.
+func (t *Tree[T]) descendFrom(skip, stop func(h *TreeNode[T], rank int) bool, iterator func(r int, i T) bool) bool {
+	var buf [maxHeight]*TreeNode[T]
+	path := buf[:0]
+	off, rank := 0, 0 // rank is the rank of the top of path
+	for h := t.root; h != nil; {
+		if r := off + size(h.Left) + 1; skip(h, r) {
+			h = h.Left
+		} else {
+			path = append(path, h)
+			off, rank = r, r
+			h = h.Right
+		}
 	}
-	if less(h.Item, pivot) || !less(pivot, h.Item) {
-		if !t.descendLessOrEqual(h.Right, pivot, iterator) {
-			return false
+	for ; len(path) > 0; rank-- {
+		h := path[len(path)-1]
+		path = path[:len(path)-1]
+		if stop(h, rank) {
+			return true
 		}
-		if !iterator(h.Item) {
+		if !iterator(rank, h.Item) {
 			return false
 		}
+		for h = h.Left; h != nil; h = h.Right {
+			path = append(path, h)
+		}
 	}
-	return t.descendLessOrEqual(h.Left, pivot, iterator)
+	return true
 }
@@ -1,6 +1,7 @@
 package llrb
 
 import (
+	"math/rand"
 	"reflect"
 	"testing"
 )
@@ -109,3 +110,114 @@ func TestAscendLessThan(t *testing.T) {
 		t.Errorf("expected %v but got %v", expected, ary)
 	}
 }
+
+func TestAscendDescendFamily(t *testing.T) {
+	tree, model := newTestTree(300)
+	for k := 0; k < 300; k++ {
+		lo, hi := Int(rand.Intn(700)-50), Int(rand.Intn(700)-50)
+		limit := rand.Intn(len(model)+1) - 1 // -1 means the iterator never stops the walk
+		for _, c := range []struct {
+			name string
+			walk func(iterator func(i Item) bool)
+			keep func(i Int) bool
+			desc bool
+		}{
+			{"Ascend", tree.Ascend, func(Int) bool { return true }, false},
+			{"AscendRange", func(it func(Item) bool) { tree.AscendRange(lo, hi, it) }, func(i Int) bool { return lo <= i && i < hi }, false},
+			{"AscendGreaterOrEqual", func(it func(Item) bool) { tree.AscendGreaterOrEqual(lo, it) }, func(i Int) bool { return lo <= i }, false},
+			{"AscendGreaterThan", func(it func(Item) bool) { tree.AscendGreaterThan(lo, it) }, func(i Int) bool { return lo < i }, false},
+			{"AscendLessThan", func(it func(Item) bool) { tree.AscendLessThan(hi, it) }, func(i Int) bool { return i < hi }, false},
+			{"AscendLessOrEqual", func(it func(Item) bool) { tree.AscendLessOrEqual(hi, it) }, func(i Int) bool { return i <= hi }, false},
+			{"Descend", tree.Descend, func(Int) bool { return true }, true},
+			{"DescendRange", func(it func(Item) bool) { tree.DescendRange(hi, lo, it) }, func(i Int) bool { return lo < i && i <= hi }, true},
+			{"DescendLessOrEqual", func(it func(Item) bool) { tree.DescendLessOrEqual(hi, it) }, func(i Int) bool { return i <= hi }, true},
+			{"DescendLessThan", func(it func(Item) bool) { tree.DescendLessThan(hi, it) }, func(i Int) bool { return i < hi }, true},
+			{"DescendGreaterThan", func(it func(Item) bool) { tree.DescendGreaterThan(lo, it) }, func(i Int) bool { return lo < i }, true},
+			{"DescendGreaterOrEqual", func(it func(Item) bool) { tree.DescendGreaterOrEqual(lo, it) }, func(i Int) bool { return lo <= i }, true},
+		} {
+			var expected []Item
+			for j := range model {
+				if c.desc {
+					j = len(model) - 1 - j
+				}
+				if c.keep(model[j]) && (limit < 0 || len(expected) < limit) {
+					expected = append(expected, model[j])
+				}
+			}
+			var ary []Item
+			c.walk(func(i Item) bool {
+				if limit >= 0 && len(ary) == limit {
+					return false
+				}
+				ary = append(ary, i)
+				return true
+			})
+			if !reflect.DeepEqual(ary, expected) {
+				t.Fatalf("%v(lo=%v, hi=%v, limit=%v):\nexpected %v\nreality  %v",
+					c.name, lo, hi, limit, expected, ary)
+			}
+		}
+	}
+}
+
+func TestInfBounds(t *testing.T) {
+	tree := New()
+	tree.InsertNoReplaceBulk(Int(4), Int(6), Int(1), Int(3))
+	var ary []Item
+	tree.DescendRange(Inf(1), Inf(-1), func(i Item) bool {
+		ary = append(ary, i)
+		return true
+	})
+	tree.AscendRange(Inf(-1), Int(4), func(i Item) bool {
+		ary = append(ary, i)
+		return true
+	})
+	tree.AscendGreaterThan(Int(3), func(i Item) bool {
+		ary = append(ary, i)
+		return true
+	})
+	expected := []Item{Int(6), Int(4), Int(3), Int(1), Int(1), Int(3), Int(4), Int(6)}
+	if !reflect.DeepEqual(ary, expected) {
+		t.Errorf("expected %v but got %v", expected, ary)
+	}
+}
+
+func TestRankRange(t *testing.T) {
+	tree, model := newTestTree(300)
+	for k := 0; k < 300; k++ {
+		from, to := rand.Intn(len(model)+10)-5, rand.Intn(len(model)+10)-5
+		limit := rand.Intn(30) - 1 // -1 means the iterator never stops the walk
+		var expectedAsc, expectedDesc []Item
+		for r := 1; r <= len(model); r++ {
+			if from <= r && r < to && (limit < 0 || len(expectedAsc) < limit) {
+				expectedAsc = append(expectedAsc, model[r-1])
+			}
+		}
+		for r := len(model); r >= 1; r-- {
+			if to < r && r <= from && (limit < 0 || len(expectedDesc) < limit) {
+				expectedDesc = append(expectedDesc, model[r-1])
+			}
+		}
+		var asc, desc []Item
+		tree.AscendRankRange(from, to, func(i Item) bool {
+			if limit >= 0 && len(asc) == limit {
+				return false
+			}
+			asc = append(asc, i)
+			return true
+		})
+		tree.DescendRankRange(from, to, func(i Item) bool {
+			if limit >= 0 && len(desc) == limit {
+				return false
+			}
+			desc = append(desc, i)
+			return true
+		})
+		if !reflect.DeepEqual(asc, expectedAsc) {
+			t.Fatalf("AscendRankRange(%v, %v), limit %v:\nexpected %v\nreality  %v", from, to, limit, expectedAsc, asc)
+		}
+		if !reflect.DeepEqual(desc, expectedDesc) {
+			t.Fatalf("DescendRankRange(%v, %v), limit %v:\nexpected %v\nreality  %v", from, to, limit, expectedDesc, desc)
+		}
+	}
+}
@@ -4,35 +4,38 @@
 
 package llrb
 
-// GetHeight returns an item in the tree with key @key, and it's height in the tree
-func (t *LLRB) GetHeight(key Item) (result Item, depth int) {
+// GetHeight returns an item in the tree with key @key, and it's height in the tree,
+// ok is false if there is no such item, depth is then the one where the search stopped
+func (t *Tree[T]) GetHeight(key T) (result T, depth int, ok bool) {
 	return t.getHeight(t.root, key)
 }
 
-func (t *LLRB) getHeight(h *Node, item Item) (Item, int) {
-	if h == nil {
-		return nil, 0
-	}
-	if less(item, h.Item) {
-		result, depth := t.getHeight(h.Left, item)
-		return result, depth + 1
-	}
-	if less(h.Item, item) {
-		result, depth := t.getHeight(h.Right, item)
-		return result, depth + 1
+func (t *Tree[T]) getHeight(h *TreeNode[T], item T) (T, int, bool) {
+	depth := 0
+	for ; h != nil; depth++ {
+		c := t.compare(item, h.Item)
+		if c == 0 {
+			return h.Item, depth, true
+		}
+		if c < 0 {
+			h = h.Left
+		} else {
+			h = h.Right
+		}
 	}
-	return h.Item, 0
+	var zero T
+	return zero, depth, false
 }
 
 // HeightStats returns the average and standard deviation of the height
 // of elements in the tree
-func (t *LLRB) HeightStats() (avg, stddev float64) {
+func (t *Tree[T]) HeightStats() (avg, stddev float64) {
 	av := &avgVar{}
 	heightStats(t.root, 0, av)
 	return av.GetAvg(), av.GetStdDev()
 }
 
-func heightStats(h *Node, d int, av *avgVar) {
+func heightStats[T any](h *TreeNode[T], d int, av *avgVar) {
 	if h == nil {
 		return
 	}
@@ -16,44 +16,59 @@
 //
 package llrb
 
-import (
-	"fmt"
-	"strings"
-)
-
 // LLRB is an order statistic tree,
-// this is an augmented Left-Leaning Red-Black (LLRB) implementation of 2-3 trees
+// this is an augmented Left-Leaning Red-Black (LLRB) implementation of 2-3 trees.
+// It is a Tree of Items, ordered by their Less method.
 type LLRB struct {
-	count int
-	root  *Node
+	Tree[Item]
 }
 
-type Node struct {
-	Item
-	Left, Right *Node // Pointers to left and right child nodes
-	Black       bool  // If set, the color of the link (incoming from the parent) is black
-	// In the LLRB, new nodes are always red, hence the zero-value for node
-
-	// size of the subtree that has root is this Node,
-	// NDescendants == tree_count in for the tree's root Node
-	NDescendants int
-}
+// Node is a node of an LLRB.
+type Node = TreeNode[Item]
 
 type Item interface {
 	Less(than Item) bool
 }
 
-//
+// Comparer is an optional interface of Items. When the key of a lookup, an insert
+// or a delete implements it, the tree compares the key with each visited node by
+// a single call to Compare instead of up to two calls to Less.
+type Comparer interface {
+	Item
+	// Compare returns a negative number if the item is less than than,
+	// zero if they have the same order and a positive number otherwise.
+	// It must be consistent with Less.
+	Compare(than Item) int
+}
+
+// less compares two items, Inf(1) and Inf(-1) may be on either side
 func less(x, y Item) bool {
-	if x == pinf {
+	if x == pinf || y == ninf {
 		return false
 	}
-	if x == ninf {
+	if x == ninf || y == pinf {
 		return true
 	}
 	return x.Less(y)
 }
 
+// compare is the three-way comparison of an LLRB,
+// it uses Comparer if x implements it and falls back to less.
+func compare(x, y Item) int {
+	if x != pinf && x != ninf && y != pinf && y != ninf {
+		if c, ok := x.(Comparer); ok {
+			return c.Compare(y)
+		}
+	}
+	if less(x, y) {
+		return -1
+	}
+	if less(y, x) {
+		return 1
+	}
+	return 0
+}
+
 // Inf returns an Item that is "bigger than" any other item, if sign is positive.
 // Otherwise  it returns an Item that is "smaller than" any other item.
 func Inf(sign int) Item {
@@ -85,566 +100,250 @@ func (pInf) Less(Item) bool {
 
 // New allocates a new tree
 func New() *LLRB {
-	return &LLRB{}
-}
-
-// SetRoot sets the root node of the tree.
-// It is intended to be used by functions that deserialize the tree.
-func (t *LLRB) SetRoot(r *Node) {
-	t.root = r
+	return &LLRB{Tree: Tree[Item]{less: less, cmp: compare}}
 }
 
-// Root returns the root node of the tree.
-// It is intended to be used by functions that serialize the tree.
-func (t *LLRB) Root() *Node {
-	return t.root
-}
-
-// Len returns the number of nodes in the tree.
-func (t *LLRB) Len() int { return t.count }
-
-// Has returns true if the tree contains an element whose order is the same as that of key.
-func (t *LLRB) Has(key Item) bool {
-	return t.Get(key) != nil
+// NewWithOptions allocates a new tree configured with opts, e.g.
+// NewWithOptions(Mode234) for a tree balanced as a 2-3-4 tree.
+func NewWithOptions(opts ...Option) *LLRB {
+	t := New()
+	t.setOptions(opts)
+	return t
 }
 
 // Get retrieves an element from the tree whose order is the same as that of key.
 func (t *LLRB) Get(key Item) Item {
-	h := t.root
-	for h != nil {
-		switch {
-		case less(key, h.Item):
-			h = h.Left
-		case less(h.Item, key):
-			h = h.Right
-		default:
-			return h.Item
-		}
-	}
-	return nil
+	item, _ := t.Tree.Get(key)
+	return item
 }
 
 // Min returns the minimum element in the tree.
 func (t *LLRB) Min() Item {
-	h := t.root
-	if h == nil {
-		return nil
-	}
-	for h.Left != nil {
-		h = h.Left
-	}
-	return h.Item
+	item, _ := t.Tree.Min()
+	return item
 }
 
 // Max returns the maximum element in the tree.
 func (t *LLRB) Max() Item {
-	h := t.root
-	if h == nil {
-		return nil
-	}
-	for h.Right != nil {
-		h = h.Right
-	}
-	return h.Item
-}
-
-func (t *LLRB) ReplaceOrInsertBulk(items ...Item) {
-	for _, i := range items {
-		t.ReplaceOrInsert(i)
-	}
-}
-
-func (t *LLRB) InsertNoReplaceBulk(items ...Item) {
-	for _, i := range items {
-		t.InsertNoReplace(i)
-	}
+	item, _ := t.Tree.Max()
+	return item
 }
 
 // ReplaceOrInsert inserts item into the tree. If an existing
 // element has the same order, it is removed from the tree and returned.
 func (t *LLRB) ReplaceOrInsert(item Item) Item {
-	// TODO: correct NDescendants
 	if item == nil {
 		panic("inserting nil item")
 	}
-	var replaced Item
-	t.root, replaced = t.replaceOrInsert(t.root, item)
-	t.root.Black = true
-	if replaced == nil {
-		t.count++
-	}
+	replaced, _ := t.Tree.ReplaceOrInsert(item)
 	return replaced
 }
 
-func (t *LLRB) replaceOrInsert(h *Node, item Item) (*Node, Item) {
-	if h == nil {
-		return newNode(item), nil
-	}
-
-	h = walkDownRot23(h)
-
-	var replaced Item
-	if less(item, h.Item) { // BUG
-		h.Left, replaced = t.replaceOrInsert(h.Left, item)
-		if replaced == nil {
-			h.NDescendants++
-		}
-	} else if less(h.Item, item) {
-		h.Right, replaced = t.replaceOrInsert(h.Right, item)
-		if replaced == nil {
-			h.NDescendants++
-		}
-	} else {
-		replaced, h.Item = h.Item, item
-	}
-
-	h = walkUpRot23(h)
-
-	return h, replaced
-}
-
 // InsertNoReplace inserts item into the tree. If an existing
 // element has the same order, both elements remain in the tree.
 func (t *LLRB) InsertNoReplace(item Item) {
 	if item == nil {
 		panic("inserting nil item")
 	}
-	t.root = t.insertNoReplace(t.root, item)
-	t.root.Black = true
-	t.count++
-}
-
-func (t *LLRB) insertNoReplace(h *Node, item Item) *Node {
-	if h == nil {
-		return newNode(item)
-	}
-
-	h = walkDownRot23(h)
-
-	h.NDescendants += 1
-	if less(item, h.Item) {
-		h.Left = t.insertNoReplace(h.Left, item)
-	} else {
-		h.Right = t.insertNoReplace(h.Right, item)
-	}
-
-	return walkUpRot23(h)
+	t.Tree.InsertNoReplace(item)
 }
 
-// Rotation driver routines for 2-3 algorithm
-
-// walkDownRot23 does nothing
-func walkDownRot23(h *Node) *Node { return h }
-
-func walkUpRot23(h *Node) *Node {
-	if isRed(h.Right) && !isRed(h.Left) {
-		h = rotateLeft(h)
-	}
-
-	if isRed(h.Left) && isRed(h.Left.Left) {
-		h = rotateRight(h)
-	}
-
-	if isRed(h.Left) && isRed(h.Right) {
-		flip(h)
-	}
-
-	return h
-}
-
-// Rotation driver routines for 2-3-4 algorithm
-
-func walkDownRot234(h *Node) *Node {
-	if isRed(h.Left) && isRed(h.Right) {
-		flip(h)
+func (t *LLRB) ReplaceOrInsertBulk(items ...Item) {
+	for _, i := range items {
+		t.ReplaceOrInsert(i)
 	}
-
-	return h
 }
 
-func walkUpRot234(h *Node) *Node {
-	if isRed(h.Right) && !isRed(h.Left) {
-		h = rotateLeft(h)
-	}
-
-	if isRed(h.Left) && isRed(h.Left.Left) {
-		h = rotateRight(h)
+func (t *LLRB) InsertNoReplaceBulk(items ...Item) {
+	for _, i := range items {
+		t.InsertNoReplace(i)
 	}
-
-	return h
 }
 
 // DeleteMin deletes the minimum element in the tree and returns the
 // deleted item or nil otherwise.
 func (t *LLRB) DeleteMin() Item {
-	var deleted Item
-	t.root, deleted = deleteMin(t.root)
-	if t.root != nil {
-		t.root.Black = true
-	}
-	if deleted != nil {
-		t.count--
-	}
+	deleted, _ := t.Tree.DeleteMin()
 	return deleted
 }
 
-// deleteMin code for LLRB 2-3 trees,
-func deleteMin(h *Node) (*Node, Item) {
-	// empty tree
-	if h == nil {
-		return nil, nil
-	}
-	// subtree rooted at h has only one node
-	if h.Left == nil {
-		return nil, h.Item
-	}
-
-	if !isRed(h.Left) && !isRed(h.Left.Left) {
-		// moveRedLeft is a combination of rotating funcs,
-		// NDescendants is handled in rotating
-		h = moveRedLeft(h)
-	}
-
-	h.NDescendants--
-	var deleted Item
-	h.Left, deleted = deleteMin(h.Left)
-
-	return fixUp(h), deleted
-}
-
 // DeleteMax deletes the maximum element in the tree and returns
 // the deleted item or nil otherwise
 func (t *LLRB) DeleteMax() Item {
-	var deleted Item
-	t.root, deleted = deleteMax(t.root)
-	if t.root != nil {
-		t.root.Black = true
-	}
-	if deleted != nil {
-		t.count--
-	}
+	deleted, _ := t.Tree.DeleteMax()
 	return deleted
 }
 
-func deleteMax(h *Node) (*Node, Item) {
-	if h == nil {
-		return nil, nil
-	}
-	if isRed(h.Left) {
-		h = rotateRight(h)
-	}
-	// subtree rooted at h has only one node
-	if h.Right == nil {
-		return nil, h.Item
-	}
-	if !isRed(h.Right) && !isRed(h.Right.Left) {
-		h = moveRedRight(h)
-	}
-
-	h.NDescendants--
-	var deleted Item
-	h.Right, deleted = deleteMax(h.Right)
-
-	return fixUp(h), deleted
-}
-
 // Delete deletes an item from the tree whose key equals key.
 // The deleted item is return, otherwise nil is returned.
 func (t *LLRB) Delete(key Item) Item {
-	var deleted Item
-	t.root, deleted = t.delete(t.root, key)
-	if t.root != nil {
-		t.root.Black = true
-	}
-	if deleted != nil {
-		t.count--
-	}
+	deleted, _ := t.Tree.Delete(key)
 	return deleted
 }
 
-// I will correct h_NDescendants after calling delete on left or right subtree
-func (t *LLRB) delete(h *Node, item Item) (*Node, Item) {
-	var deleted Item
-	if h == nil {
-		return nil, nil
-	}
-	if less(item, h.Item) {
-		if h.Left == nil { // item not present. Nothing to delete
-			return h, nil
-		}
-		if !isRed(h.Left) && !isRed(h.Left.Left) {
-			h = moveRedLeft(h)
-		}
-		h.Left, deleted = t.delete(h.Left, item)
-		if deleted != nil { // the deleting only changes h_Left subtree
-			h.NDescendants--
-		}
-	} else {
-		if isRed(h.Left) {
-			h = rotateRight(h)
-		}
-		// If @item equals @h.Item and no right children at @h
-		if !less(h.Item, item) && h.Right == nil {
-			return nil, h.Item
-		}
-		// PETAR: Added 'h.Right != nil' below
-		if h.Right != nil && !isRed(h.Right) && !isRed(h.Right.Left) {
-			h = moveRedRight(h)
-		}
-		// If @item equals @h.Item, and (from above) 'h.Right != nil'
-		if !less(h.Item, item) {
-			var subDeleted Item
-			h.Right, subDeleted = deleteMin(h.Right)
-			if subDeleted == nil {
-				panic("logic")
-			}
-			h.NDescendants--
-			deleted, h.Item = h.Item, subDeleted
-		} else { // Else, @item is bigger than @h.Item
-			h.Right, deleted = t.delete(h.Right, item)
-			if deleted != nil { // the deleting only changes h_Right subtree
-				h.NDescendants--
-			}
-		}
-	}
-
-	return fixUp(h), deleted
+// DeleteByRank deletes the Item with rank r (rank start from 1) from the tree.
+// The deleted item is returned, nil is returned if r is not in [1, t.Len()].
+func (t *LLRB) DeleteByRank(r int) Item {
+	deleted, _ := t.Tree.DeleteByRank(r)
+	return deleted
 }
 
-// Internal node manipulation routines
-
-func newNode(item Item) *Node {
-	return &Node{
-		Item:         item,
-		NDescendants: 1,
-	}
+// DeleteExact deletes the first Item whose order is the same as that of item
+// and for which eq(Item, item) is true. The deleted item is returned,
+// otherwise nil is returned.
+func (t *LLRB) DeleteExact(item Item, eq func(a, b Item) bool) Item {
+	deleted, _ := t.Tree.DeleteExact(item, eq)
+	return deleted
 }
 
-func isRed(h *Node) bool {
-	if h == nil {
-		return false
+// GetByRank retrieves an Item with a given rank r (rank start from 1).
+// this func only returns nil if the tree has length 0 or the tree is invalid.
+func (t *LLRB) GetByRank(r int) Item {
+	item, ok := t.Tree.GetByRank(r)
+	if !ok {
+		if r <= 0 {
+			return t.Min()
+		} else { // r > tree_length
+			return t.Max()
+		}
 	}
-	return !h.Black
+	return item
 }
 
-func rotateLeft(h *Node) *Node {
-	parentSize := h.NDescendants
-	leftChildSize := size(h.Left)
-	rightChildL1LeftChildL2Size := size(h.Right.Left)
-
-	x := h.Right
-	if x.Black {
-		panic("rotating a black link")
-	}
-	h.Right = x.Left
-	x.Left = h
-	x.Black = h.Black
-	h.Black = false
-
-	x.NDescendants = parentSize
-	h.NDescendants = leftChildSize + rightChildL1LeftChildL2Size + 1
-
-	return x
+// GetRankOf determines rank of an key (rank start from 1),
+// this func returns the rank and the first Item in the tree that equal to key,
+// in case key does not exist, this func returns the rank key would have
+// if it were inserted and nil Item.
+func (t *LLRB) GetRankOf(key Item) (int, Item) {
+	r, item, _ := t.Tree.GetRankOf(key)
+	return r, item
 }
 
-func rotateRight(h *Node) *Node {
-	parentSize := h.NDescendants
-	rightChildSize := size(h.Right)
-	leftChildL1rightChildL2Size := size(h.Left.Right)
-
-	x := h.Left
-	if x.Black {
-		panic("rotating a black link")
-	}
-	h.Left = x.Right
-	x.Right = h
-	x.Black = h.Black
-	h.Black = false
+// GetHeight returns an item in the tree with key @key, and it's height in the tree
+func (t *LLRB) GetHeight(key Item) (result Item, depth int) {
+	result, depth, _ = t.Tree.GetHeight(key)
+	return result, depth
+}
 
-	x.NDescendants = parentSize
-	h.NDescendants = rightChildSize + leftChildL1rightChildL2Size + 1
+// Floor returns the greatest Item less or equal to key and its rank,
+// or nil and 0 if there is no such Item.
+func (t *LLRB) Floor(key Item) (Item, int) {
+	item, rank, _ := t.Tree.Floor(key)
+	return item, rank
+}
 
-	return x
+// Lower returns the greatest Item strictly less than key and its rank,
+// or nil and 0 if there is no such Item.
+func (t *LLRB) Lower(key Item) (Item, int) {
+	item, rank, _ := t.Tree.Lower(key)
+	return item, rank
 }
 
-// flip changes color of the node and its children,
-// only nodes's color are changed, nodes's NDescendants are unchanged,
-// REQUIRE: Left and Right children must be present
-func flip(h *Node) {
-	h.Black = !h.Black
-	h.Left.Black = !h.Left.Black
-	h.Right.Black = !h.Right.Black
+// Ceiling returns the least Item greater or equal to key and its rank,
+// or nil and 0 if there is no such Item.
+func (t *LLRB) Ceiling(key Item) (Item, int) {
+	item, rank, _ := t.Tree.Ceiling(key)
+	return item, rank
 }
 
-// REQUIRE: Left and Right children must be present
-func moveRedLeft(h *Node) *Node {
-	flip(h)
-	if isRed(h.Right.Left) {
-		h.Right = rotateRight(h.Right)
-		h = rotateLeft(h)
-		flip(h)
-	}
-	return h
+// Higher returns the least Item strictly greater than key and its rank,
+// or nil and 0 if there is no such Item.
+func (t *LLRB) Higher(key Item) (Item, int) {
+	item, rank, _ := t.Tree.Higher(key)
+	return item, rank
 }
 
-// REQUIRE: Left and Right children must be present
-func moveRedRight(h *Node) *Node {
-	flip(h)
-	if isRed(h.Left.Left) {
-		h = rotateRight(h)
-		flip(h)
-	}
-	return h
+// SelectByWeight returns the Item where the running total of the weights passes w
+// and its rank, or nil and 0 if w is not in [0, t.TotalWeight()), see Tree.SelectByWeight.
+// The tree must be augmented with Weights.
+func (t *LLRB) SelectByWeight(w int64) (Item, int) {
+	item, rank, _ := t.Tree.SelectByWeight(w)
+	return item, rank
 }
 
-func fixUp(h *Node) *Node {
-	if isRed(h.Right) {
-		h = rotateLeft(h)
-	}
+// Quantile returns the q-quantile of the Items selected with the method m,
+// or nil if the tree is empty or q is not in [0, 1], see QuantileMethod.
+func (t *LLRB) Quantile(q float64, m QuantileMethod) Item {
+	item, _ := t.Tree.Quantile(q, m)
+	return item
+}
 
-	if isRed(h.Left) && isRed(h.Left.Left) {
-		h = rotateRight(h)
-	}
+// Median returns the middle Item, or the two middle Items if the tree has an even
+// number of Items (lower and upper are the same Item otherwise), or nil and nil
+// if the tree is empty.
+func (t *LLRB) Median() (lower, upper Item) {
+	lower, upper, _ = t.Tree.Median()
+	return lower, upper
+}
 
-	if isRed(h.Left) && isRed(h.Right) {
-		flip(h)
-	}
+// Split moves the Items less than key to left and the others to right, in O(log n).
+// t is empty afterwards.
+func (t *LLRB) Split(key Item) (left, right *LLRB) {
+	l, r := t.Tree.Split(key)
+	return &LLRB{Tree: *l}, &LLRB{Tree: *r}
+}
 
-	return h
+// SplitAtRank moves the Items with rank less than r to left and the others
+// to right, in O(log n). t is empty afterwards.
+func (t *LLRB) SplitAtRank(r int) (left, right *LLRB) {
+	lt, rt := t.Tree.SplitAtRank(r)
+	return &LLRB{Tree: *lt}, &LLRB{Tree: *rt}
 }
 
-// size is convenient to get node_NDescendants (node can be nil)
-func size(h *Node) int {
-	if h == nil {
-		return 0
-	}
-	return h.NDescendants
+// Join returns a tree with the Items of left followed by the Items of right,
+// in O(log n). No Item of right may be less than an Item of left.
+// left and right are empty afterwards.
+func Join(left, right *LLRB) *LLRB {
+	return &LLRB{Tree: *JoinTree(&left.Tree, &right.Tree)}
 }
 
-func (h *Node) String() string {
-	if h != nil {
-		return fmt.Sprintf("[k:%v,%v,%v]",
-			h.Item, h.NDescendants, h.Black)
-	} else {
-		return "nil"
-	}
+// Union returns a tree with the Items of a and b, in O(n + m). An Item of a
+// equal to an Item of b is replaced with resolve(itemOfA, itemOfB) if resolve is not nil,
+// otherwise the Item of a is kept. a and b are unchanged.
+func Union(a, b *LLRB, resolve func(a, b Item) Item) *LLRB {
+	return &LLRB{Tree: *UnionTree(&a.Tree, &b.Tree, resolve)}
 }
 
-func (t *LLRB) stringBFS() string {
-	lines := make([]string, 0)
-	visiteds := make(map[*Node]bool, t.count)
-	type QueueElem struct {
-		node   *Node
-		parent string
-	}
-	q := []QueueElem{{node: t.root, parent: "IAmRoot"}}
-	for len(q) > 0 {
-		pop := q[0]
-		q = q[1:]
-		visiteds[pop.node] = true
-		parentStr := fmt.Sprintf("%v", pop.node.Item)
-		if pop.node.Left != nil && !visiteds[pop.node.Left] {
-			q = append(q, QueueElem{node: pop.node.Left, parent: parentStr})
-		}
-		if pop.node.Right != nil && !visiteds[pop.node.Right] {
-			q = append(q, QueueElem{node: pop.node.Right, parent: parentStr})
-		}
-		line := fmt.Sprintf("parent: %v, node: %v, ", pop.parent, pop.node)
-		lines = append(lines, line)
-	}
-	return strings.Join(lines, "\n")
+// Intersection returns a tree with the Items of a that are also in b, in O(n + m).
+// With duplicates, an Item of b keeps only one equal Item of a. a and b are unchanged.
+func Intersection(a, b *LLRB) *LLRB {
+	return &LLRB{Tree: *IntersectionTree(&a.Tree, &b.Tree)}
 }
 
-// GetByRank retrieves an Item with a given rank r (rank start from 1).
-// this func only returns nil if the tree has length 0 or the tree is invalid.
-func (t *LLRB) GetByRank(r int) Item {
-	node := t.getByRank(t.root, r)
-	if node == nil {
-		if r <= 0 {
-			return t.Min()
-		} else { // r > tree_length
-			return t.Max()
-		}
-	}
-	return node.Item
+// Difference returns a tree with the Items of a that are not in b, in O(n + m).
+// With duplicates, an Item of b removes only one equal Item of a. a and b are unchanged.
+func Difference(a, b *LLRB) *LLRB {
+	return &LLRB{Tree: *DifferenceTree(&a.Tree, &b.Tree)}
 }
 
-func (t *LLRB) getByRank(h *Node, r int) *Node {
-	if h == nil {
-		return nil
-	}
-	hRank := size(h.Left) + 1
-	if r == hRank {
-		return h
+// FromSorted returns a tree holding items in O(n), items must be in ascending order
+// (duplicates are allowed), otherwise an error wrapping ErrNotSorted is returned.
+func FromSorted(items []Item) (*LLRB, error) {
+	t := New()
+	if err := t.AppendSorted(items); err != nil {
+		return nil, err
 	}
-	if r < hRank {
-		if h.Left == nil { // never expected to reach this branch
-			return nil
-		}
-		return t.getByRank(h.Left, r)
-	}
-	if h.Right == nil { // never expected to reach this branch
-		return nil
-	}
-	return t.getByRank(h.Right, r-hRank)
+	return t, nil
 }
 
-// GetRankOf determines rank of an key (rank start from 1),
-// this func returns the rank and one Item in the tree that equal to key,
-// in case key does not exist, this func returns approximate rank and nil Item.
-func (t *LLRB) GetRankOf(key Item) (int, Item) {
-	path := t.get(key)
-	//fmt.Println("path: ", path)
-	return t.getRankOf(path, key)
-}
-
-// get returns path from the root to a node whose order is the same as that of key,
-// path[last] is nil if the tree does not contain exact key Item
-func (t *LLRB) get(key Item) []*Node {
-	path := make([]*Node, 0)
-	h := t.root
-	for h != nil {
-		path = append(path, h)
-		switch {
-		case less(key, h.Item):
-			h = h.Left
-		case less(h.Item, key):
-			h = h.Right
-		default: // exactly equal
-			return path
+// AppendSorted inserts items after the Items of the tree in O(k + log n) for k items.
+// items must be in ascending order and none of them may be less than t.Max(),
+// otherwise the tree is unchanged and an error wrapping ErrNotSorted is returned.
+func (t *LLRB) AppendSorted(items []Item) error {
+	for _, i := range items {
+		if i == nil {
+			panic("inserting nil item")
 		}
 	}
-	path = append(path, nil)
-	return path
+	return t.Tree.AppendSorted(items)
 }
 
-func (t *LLRB) getRankOf(path []*Node, key Item) (int, Item) {
-	if len(path) < 1 {
-		return 0, nil
-	}
-	foundItem := path[len(path)-1]
-	var r int
-	if foundItem != nil {
-		r = size(foundItem.Left) + 1
-	} else { // return rank of nearest parent node for non-existed item
-		if len(path) < 2 {
-			return 0, nil
-		}
-		r = size(path[len(path)-2].Left) + 1
-	}
-	for i := len(path) - 1; i >= 0; i-- {
-		if path[i] == nil {
-			continue
-		}
-		if i-1 < 0 || path[i-1] == nil {
-			continue
-		}
-		if path[i] == path[i-1].Right { // if current node is a right node
-			r += size(path[i-1].Left) + 1 // add size of the left sibling to the rank
-		}
-	}
-	if foundItem == nil { // workaround for Go nil interface
-		return r, nil
-	}
-	return r, foundItem
+// Clone returns a copy of the tree in O(1), see Tree.Clone.
+// To make a new version of a tree and keep the old one readable,
+// modify a clone of it:
+//
+//	next := t.Clone()
+//	next.ReplaceOrInsert(item) // copies only the nodes on the path to item
+func (t *LLRB) Clone() *LLRB {
+	return &LLRB{Tree: *t.Tree.Clone()}
 }
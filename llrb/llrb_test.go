@@ -5,186 +5,219 @@
 package llrb
 
 import (
+	"fmt"
 	"math"
 	"math/rand"
+	"slices"
+	"sort"
+	"strings"
 	"testing"
 )
 
 func TestCases(t *testing.T) {
-	tree := New()
-	tree.ReplaceOrInsert(Int(1))
-	tree.ReplaceOrInsert(Int(1))
-	if tree.Len() != 1 {
-		t.Errorf("expecting len 1")
-	}
-	if !tree.Has(Int(1)) {
-		t.Errorf("expecting to find key=1")
-	}
+	forEachTree(t, func(t *testing.T, tree testTree[Int]) {
+		tree.ReplaceOrInsert(1)
+		tree.ReplaceOrInsert(1)
+		if tree.Len() != 1 {
+			t.Errorf("expecting len 1")
+		}
+		if !tree.Has(1) {
+			t.Errorf("expecting to find key=1")
+		}
 
-	tree.Delete(Int(1))
-	if tree.Len() != 0 {
-		t.Errorf("expecting len 0")
-	}
-	if tree.Has(Int(1)) {
-		t.Errorf("not expecting to find key=1")
-	}
+		tree.Delete(1)
+		if tree.Len() != 0 {
+			t.Errorf("expecting len 0")
+		}
+		if tree.Has(1) {
+			t.Errorf("not expecting to find key=1")
+		}
 
-	tree.Delete(Int(1))
-	if tree.Len() != 0 {
-		t.Errorf("expecting len 0")
-	}
-	if tree.Has(Int(1)) {
-		t.Errorf("not expecting to find key=1")
-	}
+		if _, ok := tree.Delete(1); ok || tree.Len() != 0 {
+			t.Errorf("deleted from an empty tree")
+		}
+		if tree.Has(1) {
+			t.Errorf("not expecting to find key=1")
+		}
+	})
 }
 
 func TestReverseInsertOrder(t *testing.T) {
-	tree := New()
-	n := 100
-	for i := 0; i < n; i++ {
-		tree.ReplaceOrInsert(Int(n - i))
-	}
-	i := 0
-	tree.AscendGreaterOrEqual(Int(0), func(item Item) bool {
-		i++
-		if item.(Int) != Int(i) {
-			t.Errorf("bad order: got %d, expect %d", item.(Int), i)
+	forEachTree(t, func(t *testing.T, tree testTree[Int]) {
+		n := 100
+		for i := 0; i < n; i++ {
+			tree.ReplaceOrInsert(Int(n - i))
 		}
-		return true
+		i := 0
+		tree.AscendGreaterOrEqual(0, func(item Int) bool {
+			i++
+			if item != Int(i) {
+				t.Errorf("bad order: got %d, expect %d", item, i)
+			}
+			return true
+		})
 	})
 }
 
 func TestRange(t *testing.T) {
-	tree := New()
-	order := []String{
-		"ab", "aba", "abc", "a", "aa", "aaa", "b", "a-", "a!",
-	}
-	for _, i := range order {
-		tree.ReplaceOrInsert(i)
-	}
-	k := 0
-	tree.AscendRange(String("ab"), String("ac"), func(item Item) bool {
-		if k > 3 {
-			t.Fatalf("returned more items than expected")
-		}
-		i1 := order[k]
-		i2 := item.(String)
-		if i1 != i2 {
-			t.Errorf("expecting %s, got %s", i1, i2)
-		}
-		k++
-		return true
+	forEachTree(t, func(t *testing.T, tree testTree[String]) {
+		order := []String{
+			"ab", "aba", "abc", "a", "aa", "aaa", "b", "a-", "a!",
+		}
+		for _, i := range order {
+			tree.ReplaceOrInsert(i)
+		}
+		k := 0
+		tree.AscendRange("ab", "ac", func(item String) bool {
+			if k > 3 {
+				t.Fatalf("returned more items than expected")
+			}
+			if order[k] != item {
+				t.Errorf("expecting %s, got %s", order[k], item)
+			}
+			k++
+			return true
+		})
 	})
 }
 
 func TestRandomInsertOrder(t *testing.T) {
-	tree := New()
-	n := 1000
-	perm := rand.Perm(n)
-	for i := 0; i < n; i++ {
-		tree.ReplaceOrInsert(Int(perm[i]))
-	}
-	j := 0
-	tree.AscendGreaterOrEqual(Int(0), func(item Item) bool {
-		if item.(Int) != Int(j) {
-			t.Fatalf("bad order")
+	forEachTree(t, func(t *testing.T, tree testTree[Int]) {
+		n := 1000
+		perm := rand.Perm(n)
+		for i := 0; i < n; i++ {
+			tree.ReplaceOrInsert(Int(perm[i]))
 		}
-		j++
-		return true
+		j := 0
+		tree.AscendGreaterOrEqual(0, func(item Int) bool {
+			if item != Int(j) {
+				t.Fatalf("bad order")
+			}
+			j++
+			return true
+		})
 	})
 }
 
 func TestRandomReplace(t *testing.T) {
-	tree := New()
-	n := 100
-	perm := rand.Perm(n)
-	for i := 0; i < n; i++ {
-		tree.ReplaceOrInsert(Int(perm[i]))
-	}
-	perm = rand.Perm(n)
-	for i := 0; i < n; i++ {
-		if replaced := tree.ReplaceOrInsert(Int(perm[i])); replaced == nil || replaced.(Int) != Int(perm[i]) {
-			t.Errorf("error replacing")
+	forEachTree(t, func(t *testing.T, tree testTree[Int]) {
+		n := 100
+		perm := rand.Perm(n)
+		for i := 0; i < n; i++ {
+			tree.ReplaceOrInsert(Int(perm[i]))
 		}
-	}
+		perm = rand.Perm(n)
+		for i := 0; i < n; i++ {
+			if replaced, ok := tree.ReplaceOrInsert(Int(perm[i])); !ok || replaced != Int(perm[i]) {
+				t.Errorf("error replacing")
+			}
+		}
+	})
 }
 
 func TestRandomInsertSequentialDelete(t *testing.T) {
-	tree := New()
-	n := 1000
-	perm := rand.Perm(n)
-	for i := 0; i < n; i++ {
-		tree.ReplaceOrInsert(Int(perm[i]))
-	}
-	for i := 0; i < n; i++ {
-		tree.Delete(Int(i))
-	}
+	forEachTree(t, func(t *testing.T, tree testTree[Int]) {
+		n := 1000
+		perm := rand.Perm(n)
+		for i := 0; i < n; i++ {
+			tree.ReplaceOrInsert(Int(perm[i]))
+		}
+		for i := 0; i < n; i++ {
+			tree.Delete(Int(i))
+		}
+	})
 }
 
 func TestRandomInsertDeleteNonExistent(t *testing.T) {
-	tree := New()
-	n := 100
-	perm := rand.Perm(n)
-	for i := 0; i < n; i++ {
-		tree.ReplaceOrInsert(Int(perm[i]))
-	}
-	if tree.Delete(Int(200)) != nil {
-		t.Errorf("deleted non-existent item")
-	}
-	if tree.Delete(Int(-2)) != nil {
-		t.Errorf("deleted non-existent item")
-	}
-	for i := 0; i < n; i++ {
-		if u := tree.Delete(Int(i)); u == nil || u.(Int) != Int(i) {
-			t.Errorf("delete failed")
+	forEachTree(t, func(t *testing.T, tree testTree[Int]) {
+		n := 100
+		perm := rand.Perm(n)
+		for i := 0; i < n; i++ {
+			tree.ReplaceOrInsert(Int(perm[i]))
 		}
-	}
-	if tree.Delete(Int(200)) != nil {
-		t.Errorf("deleted non-existent item")
-	}
-	if tree.Delete(Int(-2)) != nil {
-		t.Errorf("deleted non-existent item")
-	}
+		if _, ok := tree.Delete(200); ok {
+			t.Errorf("deleted non-existent item")
+		}
+		if _, ok := tree.Delete(-2); ok {
+			t.Errorf("deleted non-existent item")
+		}
+		for i := 0; i < n; i++ {
+			if u, ok := tree.Delete(Int(i)); !ok || u != Int(i) {
+				t.Errorf("delete failed")
+			}
+		}
+		if _, ok := tree.Delete(200); ok {
+			t.Errorf("deleted non-existent item")
+		}
+		if _, ok := tree.Delete(-2); ok {
+			t.Errorf("deleted non-existent item")
+		}
+	})
 }
 
 func TestRandomInsertPartialDeleteOrder(t *testing.T) {
-	tree := New()
-	n := 100
-	perm := rand.Perm(n)
-	for i := 0; i < n; i++ {
-		tree.ReplaceOrInsert(Int(perm[i]))
-	}
-	for i := 1; i < n-1; i++ {
-		tree.Delete(Int(i))
-	}
-	j := 0
-	tree.AscendGreaterOrEqual(Int(0), func(item Item) bool {
-		switch j {
-		case 0:
-			if item.(Int) != Int(0) {
-				t.Errorf("expecting 0")
-			}
-		case 1:
-			if item.(Int) != Int(n-1) {
-				t.Errorf("expecting %d", n-1)
+	forEachTree(t, func(t *testing.T, tree testTree[Int]) {
+		n := 100
+		perm := rand.Perm(n)
+		for i := 0; i < n; i++ {
+			tree.ReplaceOrInsert(Int(perm[i]))
+		}
+		for i := 1; i < n-1; i++ {
+			tree.Delete(Int(i))
+		}
+		j := 0
+		tree.AscendGreaterOrEqual(0, func(item Int) bool {
+			switch j {
+			case 0:
+				if item != 0 {
+					t.Errorf("expecting 0")
+				}
+			case 1:
+				if item != Int(n-1) {
+					t.Errorf("expecting %d", n-1)
+				}
 			}
+			j++
+			return true
+		})
+		if j != 2 {
+			t.Errorf("expecting 2 items, got %d", j)
 		}
-		j++
-		return true
 	})
 }
 
 func TestRandomInsertStats(t *testing.T) {
+	forEachTree(t, func(t *testing.T, tree testTree[Int]) {
+		n := 100000
+		perm := rand.Perm(n)
+		for i := 0; i < n; i++ {
+			tree.ReplaceOrInsert(Int(perm[i]))
+		}
+		avg, _ := tree.HeightStats()
+		expAvg := math.Log2(float64(n)) - 1.5
+		if math.Abs(avg-expAvg) >= 2.0 {
+			t.Errorf("too much deviation from expected average height")
+		}
+	})
+}
+
+func TestLLRB_GetHeight(t *testing.T) {
 	tree := New()
-	n := 100000
-	perm := rand.Perm(n)
-	for i := 0; i < n; i++ {
-		tree.ReplaceOrInsert(Int(perm[i]))
+	for i := 1; i <= 7; i++ {
+		tree.InsertNoReplace(Int(2 * i))
+	}
+	// 8 at the root, 4 and 12 below it, then the other leaves
+	for _, c := range []struct {
+		key   Int
+		item  Item
+		depth int
+	}{{8, Int(8), 0}, {12, Int(12), 1}, {2, Int(2), 2}, {1, nil, 3}, {9, nil, 3}, {15, nil, 3}} {
+		if item, depth := tree.GetHeight(c.key); item != c.item || depth != c.depth {
+			t.Errorf("GetHeight(%v): %v, %v, expected %v, %v", c.key, item, depth, c.item, c.depth)
+		}
 	}
-	avg, _ := tree.HeightStats()
-	expAvg := math.Log2(float64(n)) - 1.5
-	if math.Abs(avg-expAvg) >= 2.0 {
-		t.Errorf("too much deviation from expected average height")
+	if item, depth := New().GetHeight(Int(1)); item != nil || depth != 0 {
+		t.Errorf("GetHeight on an empty tree: %v, %v", item, depth)
 	}
 }
 
@@ -220,232 +253,237 @@ func BenchmarkDeleteMin(b *testing.B) {
 }
 
 func TestInsertNoReplace(t *testing.T) {
-	tree := New()
-	n := 1000
-	for q := 0; q < 2; q++ {
-		perm := rand.Perm(n)
-		for i := 0; i < n; i++ {
-			tree.InsertNoReplace(Int(perm[i]))
-		}
-	}
-	j := 0
-	tree.AscendGreaterOrEqual(Int(0), func(item Item) bool {
-		if item.(Int) != Int(j/2) {
-			t.Fatalf("bad order")
+	forEachTree(t, func(t *testing.T, tree testTree[Int]) {
+		n := 1000
+		for q := 0; q < 2; q++ {
+			perm := rand.Perm(n)
+			for i := 0; i < n; i++ {
+				tree.InsertNoReplace(Int(perm[i]))
+			}
 		}
-		j++
-		return true
+		j := 0
+		tree.AscendGreaterOrEqual(0, func(item Int) bool {
+			if item != Int(j/2) {
+				t.Fatalf("bad order")
+			}
+			j++
+			return true
+		})
 	})
 }
 
 func TestNDescendants(t *testing.T) {
-	for outerIdx, shuffle := range [][]Int{
+	for _, shuffle := range [][]Int{
 		[]Int{2, 4, 6, 8, 10, 12, 14, 16, 18, 20},
 		[]Int{16, 2, 20, 10, 8, 14, 6, 4, 12, 18},
 	} {
-		//t.Log("outerIdx:", outerIdx)
-		_ = outerIdx
-		tree := New()
-		for i := 1; i <= 10; i++ {
-			tree.InsertNoReplace(shuffle[i-1])
-		}
-		//t.Log(tree.stringBFS())
-		if tree.root.NDescendants != 10 ||
-			tree.root.Left.NDescendants != 3 ||
-			tree.root.Right.NDescendants != 6 ||
-			tree.root.Left.Left.NDescendants != 1 ||
-			tree.root.Left.Right.NDescendants != 1 ||
-			tree.root.Right.Left.NDescendants != 3 ||
-			tree.root.Right.Right.NDescendants != 2 ||
-			tree.root.Right.Left.Left.NDescendants != 1 ||
-			tree.root.Right.Left.Right.NDescendants != 1 ||
-			tree.root.Right.Right.Left.NDescendants != 1 {
-			t.Error(tree.stringBFS())
-		}
+		forEachTree(t, func(t *testing.T, tree testTree[Int]) {
+			for i := 1; i <= 10; i++ {
+				tree.InsertNoReplace(shuffle[i-1])
+			}
+			if tree.sizeAt("") != 10 ||
+				tree.sizeAt("L") != 3 ||
+				tree.sizeAt("R") != 6 ||
+				tree.sizeAt("LL") != 1 ||
+				tree.sizeAt("LR") != 1 ||
+				tree.sizeAt("RL") != 3 ||
+				tree.sizeAt("RR") != 2 ||
+				tree.sizeAt("RLL") != 1 ||
+				tree.sizeAt("RLR") != 1 ||
+				tree.sizeAt("RRL") != 1 {
+				t.Error(tree.stringBFS())
+			}
 
-		for i := 1; i <= 10; i++ {
-			if reality := tree.GetByRank(i); reality.(Int) != Int(2*i) {
-				t.Error(Int(2*i), reality)
+			for i := 1; i <= 10; i++ {
+				if reality, ok := tree.GetByRank(i); !ok || reality != Int(2*i) {
+					t.Error(Int(2*i), reality)
+				}
 			}
-		}
 
-		for i := 1; i <= 10; i++ {
-			item := Int(2 * i)
-			r, foundItem := tree.GetRankOf(item)
-			//t.Logf("item: %v, rank: %v, foundItem: %v", item, r, foundItem)
-			if r != i || foundItem == nil {
-				t.Error(i, r, foundItem)
+			for i := 1; i <= 10; i++ {
+				item := Int(2 * i)
+				r, foundItem, ok := tree.GetRankOf(item)
+				if r != i || !ok || foundItem != item {
+					t.Error(i, r, foundItem)
+				}
 			}
-		}
 
-		r, foundItem := tree.GetRankOf(Int(17))
-		if foundItem != nil || r != 9 {
-			t.Error(r, foundItem, foundItem == nil)
-		}
-		r, foundItem = tree.GetRankOf(Int(5))
-		if foundItem != nil || r != 3 {
-			t.Error(r, foundItem)
-		}
+			if r, _, ok := tree.GetRankOf(17); ok || r != 9 {
+				t.Error(r, ok)
+			}
+			if r, _, ok := tree.GetRankOf(5); ok || r != 3 {
+				t.Error(r, ok)
+			}
+		})
 	}
 }
 
 func TestLLRB_RankEmpty(t *testing.T) {
-	tree := New()
-	if tree.GetByRank(10) != nil {
-		t.Error()
-	}
-	rank, item := tree.GetRankOf(Int(10))
-	if rank != 0 || item != nil {
-		t.Error()
-	}
+	forEachTree(t, func(t *testing.T, tree testTree[Int]) {
+		if _, ok := tree.GetByRank(10); ok {
+			t.Error()
+		}
+		if rank, _, ok := tree.GetRankOf(10); rank != 0 || ok {
+			t.Error()
+		}
+	})
 }
 
 func BenchmarkLLRB_GetRankOf(b *testing.B) {
 	b.StopTimer()
 	tree := New()
+	keys := make([]Item, b.N) // boxed beforehand, so only GetRankOf is measured
 	for i := 0; i < b.N; i++ {
-		tree.InsertNoReplace(Int(i))
+		keys[i] = Int(i)
+		tree.InsertNoReplace(keys[i])
 	}
+	b.ReportAllocs()
 	b.StartTimer()
 	for i := 0; i < b.N; i++ {
-		tree.GetRankOf(Int(i))
+		tree.GetRankOf(keys[i])
 		tree.GetByRank(rand.Intn(b.N))
 	}
 }
 
-func TestLLRB_Delete(t *testing.T) {
+func TestLLRB_GetRankOfAllocs(t *testing.T) {
 	tree := New()
-	for i := 1; i <= 10; i++ {
-		tree.InsertNoReplace(Int(2 * i))
-	}
-	deleted := tree.Delete(Int(18))
-	if deleted == nil {
-		t.Fatal()
-	}
-	deleted = tree.Delete(Int(17))
-	if deleted != nil {
-		t.Fatal()
-	}
-	if tree.root.NDescendants != 9 {
-		t.Errorf("root.NDescendants: expect 9, reality: %v", tree.root.NDescendants)
-	}
-	if tree.root.Right.NDescendants != 5 {
-		t.Errorf("root.Right.Right.NDescendants: expect 5, reality: %v",
-			tree.root.Right.NDescendants)
-	}
-	if tree.root.Right.Right.NDescendants != 1 {
-		t.Errorf("root.Right.Right.NDescendants: expect 1, reality: %v",
-			tree.root.Right.Right.NDescendants)
-	}
-	//t.Log(tree.stringBFS())
-	deleted = tree.Delete(Int(12))
-	if deleted == nil {
-		t.Fatal()
+	for i := 0; i < 1000; i++ {
+		tree.InsertNoReplace(Int(i))
 	}
-	if tree.root.NDescendants != 8 || // item 8
-		tree.root.Right.NDescendants != 4 || // item 16
-		tree.root.Right.Right.NDescendants != 1 || // item 20
-		tree.root.Right.Left.NDescendants != 2 { // item 14
-		t.Error(tree.stringBFS())
+	present, missing := Item(Int(777)), Item(Int(5000))
+	allocs := testing.AllocsPerRun(100, func() {
+		tree.GetRankOf(present)
+		tree.GetRankOf(missing)
+	})
+	if allocs != 0 {
+		t.Errorf("GetRankOf made %v allocations", allocs)
 	}
 }
 
+func TestLLRB_Delete(t *testing.T) {
+	forEachTree(t, func(t *testing.T, tree testTree[Int]) {
+		for i := 1; i <= 10; i++ {
+			tree.InsertNoReplace(Int(2 * i))
+		}
+		if _, ok := tree.Delete(18); !ok {
+			t.Fatal()
+		}
+		if _, ok := tree.Delete(17); ok {
+			t.Fatal()
+		}
+		if tree.sizeAt("") != 9 {
+			t.Errorf("root.NDescendants: expect 9, reality: %v", tree.sizeAt(""))
+		}
+		if tree.sizeAt("R") != 5 {
+			t.Errorf("root.Right.NDescendants: expect 5, reality: %v", tree.sizeAt("R"))
+		}
+		if tree.sizeAt("RR") != 1 {
+			t.Errorf("root.Right.Right.NDescendants: expect 1, reality: %v", tree.sizeAt("RR"))
+		}
+		if _, ok := tree.Delete(12); !ok {
+			t.Fatal()
+		}
+		if tree.sizeAt("") != 8 || // item 8
+			tree.sizeAt("R") != 4 || // item 16
+			tree.sizeAt("RR") != 1 || // item 20
+			tree.sizeAt("RL") != 2 { // item 14
+			t.Error(tree.stringBFS())
+		}
+	})
+}
+
 func TestLLRB_Delete2(t *testing.T) {
-	tree := New()
-	for _, e := range []Int{32, 12, 4, 24, 2} {
-		tree.InsertNoReplace(e)
-	}
-	tree.Delete(Int(4))
-	for _, e := range []Int{14, 8, 36, 20, 34} {
-		tree.InsertNoReplace(e)
-	}
-	tree.Delete(Int(20))
-	for _, e := range []Int{40, 16, 30, 28, 26} {
-		tree.InsertNoReplace(e)
-	}
-	tree.Delete(Int(26))
-	tree.InsertNoReplace(Int(20))
-	for _, e := range []Int{10, 38, 22, 18, 6} {
-		tree.InsertNoReplace(e)
-	}
-	tree.Delete(Int(38))
-	for _, c := range []struct {
-		item Int
-		rank int
-	}{
-		{item: 2, rank: 1}, {item: 6, rank: 2}, {item: 8, rank: 3},
-		{item: 10, rank: 4}, {item: 12, rank: 5}, {item: 14, rank: 6},
-		{item: 16, rank: 7}, {item: 18, rank: 8}, {item: 20, rank: 9},
-		{item: 22, rank: 10}, {item: 24, rank: 11}, {item: 28, rank: 12},
-		{item: 30, rank: 13}, {item: 32, rank: 14}, {item: 34, rank: 15},
-		{item: 36, rank: 16}, {item: 40, rank: 17},
-	} {
-		r, _ := tree.GetRankOf(c.item)
-		if r != c.rank {
-			t.Errorf("item: %v, expected: %v, reality: %v", c.item, c.rank, r)
+	forEachTree(t, func(t *testing.T, tree testTree[Int]) {
+		for _, e := range []Int{32, 12, 4, 24, 2} {
+			tree.InsertNoReplace(e)
 		}
-	}
+		tree.Delete(4)
+		for _, e := range []Int{14, 8, 36, 20, 34} {
+			tree.InsertNoReplace(e)
+		}
+		tree.Delete(20)
+		for _, e := range []Int{40, 16, 30, 28, 26} {
+			tree.InsertNoReplace(e)
+		}
+		tree.Delete(26)
+		tree.InsertNoReplace(20)
+		for _, e := range []Int{10, 38, 22, 18, 6} {
+			tree.InsertNoReplace(e)
+		}
+		tree.Delete(38)
+		for _, c := range []struct {
+			item Int
+			rank int
+		}{
+			{item: 2, rank: 1}, {item: 6, rank: 2}, {item: 8, rank: 3},
+			{item: 10, rank: 4}, {item: 12, rank: 5}, {item: 14, rank: 6},
+			{item: 16, rank: 7}, {item: 18, rank: 8}, {item: 20, rank: 9},
+			{item: 22, rank: 10}, {item: 24, rank: 11}, {item: 28, rank: 12},
+			{item: 30, rank: 13}, {item: 32, rank: 14}, {item: 34, rank: 15},
+			{item: 36, rank: 16}, {item: 40, rank: 17},
+		} {
+			if r, _, _ := tree.GetRankOf(c.item); r != c.rank {
+				t.Errorf("item: %v, expected: %v, reality: %v", c.item, c.rank, r)
+			}
+		}
+	})
 }
 
 func TestLLRB_DeleteMin(t *testing.T) {
-	tree := New()
-	tree.InsertNoReplace(Int(2))
-	tree.InsertNoReplace(Int(4))
-	//t.Log(tree.stringBFS())
-	tree.DeleteMin()
-	if tree.root.NDescendants != 1 {
-		t.Errorf("expect %v, reality: %v", 1, tree.root.NDescendants)
-		t.Log(tree.stringBFS())
-	}
+	forEachTree(t, func(t *testing.T, tree testTree[Int]) {
+		tree.InsertNoReplace(2)
+		tree.InsertNoReplace(4)
+		tree.DeleteMin()
+		if tree.sizeAt("") != 1 {
+			t.Errorf("expect %v, reality: %v", 1, tree.sizeAt(""))
+			t.Log(tree.stringBFS())
+		}
+	})
 }
 
 func TestLLRB_DeleteMin2(t *testing.T) {
-	tree := New()
-	shuffle := []Int{6, 2, 10, 8, 4}
-	for _, e := range shuffle {
-		tree.InsertNoReplace(e)
-	}
+	forEachTree(t, func(t *testing.T, tree testTree[Int]) {
+		for _, e := range []Int{6, 2, 10, 8, 4} {
+			tree.InsertNoReplace(e)
+		}
 
-	tree.DeleteMin()
-	if tree.root.NDescendants != 4 ||
-		tree.root.Left.NDescendants != 1 {
-		t.Error(tree.stringBFS())
-	}
+		tree.DeleteMin()
+		if tree.sizeAt("") != 4 || tree.sizeAt("L") != 1 {
+			t.Error(tree.stringBFS())
+		}
 
-	tree.DeleteMin()
-	if tree.root.NDescendants != 3 ||
-		tree.root.Left.NDescendants != 1 {
-		t.Error(tree.stringBFS())
-	}
+		tree.DeleteMin()
+		if tree.sizeAt("") != 3 || tree.sizeAt("L") != 1 {
+			t.Error(tree.stringBFS())
+		}
+	})
 }
 
 func TestLLRB_DeleteMax(t *testing.T) {
-	tree := New()
-	tree.InsertNoReplace(Int(2))
-	tree.InsertNoReplace(Int(4))
-	//t.Log(tree.stringBFS())
-	tree.DeleteMax()
-	if tree.root.NDescendants != 1 {
-		t.Errorf(tree.stringBFS())
-	}
+	forEachTree(t, func(t *testing.T, tree testTree[Int]) {
+		tree.InsertNoReplace(2)
+		tree.InsertNoReplace(4)
+		tree.DeleteMax()
+		if tree.sizeAt("") != 1 {
+			t.Error(tree.stringBFS())
+		}
+	})
 }
 
 func TestLLRB_DeleteMax2(t *testing.T) {
-	tree := New()
-	shuffle := []Int{6, 2, 10, 8, 4}
-	for _, e := range shuffle {
-		tree.InsertNoReplace(e)
-	}
-	tree.DeleteMax()
-	if tree.root.NDescendants != 4 ||
-		tree.root.Right.NDescendants != 1 {
-		t.Error(tree.stringBFS())
-	}
-	tree.DeleteMax()
-	if tree.root.NDescendants != 3 ||
-		tree.root.Left.NDescendants != 1 {
-		t.Error(tree.stringBFS())
-	}
+	forEachTree(t, func(t *testing.T, tree testTree[Int]) {
+		for _, e := range []Int{6, 2, 10, 8, 4} {
+			tree.InsertNoReplace(e)
+		}
+		tree.DeleteMax()
+		if tree.sizeAt("") != 4 || tree.sizeAt("R") != 1 {
+			t.Error(tree.stringBFS())
+		}
+		tree.DeleteMax()
+		if tree.sizeAt("") != 3 || tree.sizeAt("L") != 1 {
+			t.Error(tree.stringBFS())
+		}
+	})
 }
 
 func TestLLRB_ReplaceOrInsert(t *testing.T) {
@@ -453,23 +491,229 @@ func TestLLRB_ReplaceOrInsert(t *testing.T) {
 		16, 15, 4, 19, 10, 24, 2, 9, 9, 5, 25, 1, 6, 2, 7, 18, 20, 4}
 	// sorted(set(a)): [1, 2, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 15, 16, 18, 19, 20, 22, 23, 24, 25]
 
-	tree := New()
-	for _, e := range array {
-		tree.ReplaceOrInsert(e)
+	forEachTree(t, func(t *testing.T, tree testTree[Int]) {
+		for _, e := range array {
+			tree.ReplaceOrInsert(e)
+		}
+		if tree.sizeAt("") != 21 {
+			t.Errorf("tree.root.NDescendants: r: %v, e: %v", tree.sizeAt(""), 21)
+		}
+		if r, _, _ := tree.GetRankOf(25); r != 21 {
+			t.Errorf("RankOf(Int(25)): r: %v, e: %v", r, 21)
+		}
+		if r, _, _ := tree.GetRankOf(4); r != 3 {
+			t.Errorf("RankOf(Int(4)): r: %v, e: %v", r, 3)
+		}
+		if r, _, _ := tree.GetRankOf(16); r != 14 {
+			t.Errorf("RankOf(Int(25)): r: %v, e: %v", r, 14)
+		}
+		if r, _, _ := tree.GetRankOf(3); r != 3 {
+			t.Errorf("RankOf(Int(3)): r: %v, e: %v", r, 3)
+		}
+	})
+}
+
+func TestRandomDeleteRank(t *testing.T) {
+	forEachTree(t, func(t *testing.T, tree testTree[Int]) {
+		n := 500
+		for _, e := range rand.Perm(n) {
+			tree.InsertNoReplace(Int(e))
+		}
+		for _, e := range rand.Perm(n)[:n/2] {
+			if _, ok := tree.Delete(Int(e)); !ok {
+				t.Fatalf("delete %v failed", e)
+			}
+		}
+		if _, ok := tree.DeleteMin(); !ok {
+			t.Fatal("DeleteMin failed")
+		}
+		if _, ok := tree.DeleteMax(); !ok {
+			t.Fatal("DeleteMax failed")
+		}
+		if tree.Len() != n/2-2 || tree.sizeAt("") != tree.Len() {
+			t.Fatalf("len: %v, root.NDescendants: %v", tree.Len(), tree.sizeAt(""))
+		}
+		r := 0
+		tree.AscendGreaterOrEqual(-1, func(item Int) bool {
+			r++
+			if got, _ := tree.GetByRank(r); got != item {
+				t.Errorf("GetByRank(%v): expected %v, reality: %v", r, item, got)
+			}
+			if got, _, _ := tree.GetRankOf(item); got != r {
+				t.Errorf("GetRankOf(%v): expected %v, reality: %v", item, r, got)
+			}
+			return true
+		})
+	})
+}
+
+func TestLLRB_DeleteByRank(t *testing.T) {
+	tree, model := newTestTree(500)
+	if tree.DeleteByRank(0) != nil || tree.DeleteByRank(len(model)+1) != nil {
+		t.Fatal("deleted an out of range rank")
+	}
+	for len(model) > 0 {
+		r := 1 + rand.Intn(len(model))
+		if deleted := tree.DeleteByRank(r); deleted != model[r-1] {
+			t.Fatalf("DeleteByRank(%v): expected %v, reality: %v", r, model[r-1], deleted)
+		}
+		model = append(model[:r-1], model[r:]...)
+		if tree.Len() != len(model) || size(tree.root) != len(model) {
+			t.Fatalf("expected len %v, reality: %v, root.NDescendants: %v",
+				len(model), tree.Len(), size(tree.root))
+		}
+		if len(model) > 0 && rand.Intn(20) == 0 {
+			for i, e := range model {
+				if item := tree.GetByRank(i + 1); item != e {
+					t.Fatalf("GetByRank(%v): expected %v, reality: %v", i+1, e, item)
+				}
+			}
+		}
+	}
+	if tree.root != nil {
+		t.Error("tree must be empty")
+	}
+}
+
+func TestLLRB_DeleteDuplicates(t *testing.T) {
+	for k := 0; k < 200; k++ {
+		tree, model := newTestTree(rand.Intn(100))
+		for d := 3 * len(model); d >= 0; d-- {
+			key := Int(rand.Intn(2*len(model) + 2))
+			j := sort.Search(len(model), func(j int) bool { return model[j] >= key })
+			deleted := tree.Delete(key)
+			if j < len(model) && model[j] == key {
+				if deleted != key {
+					t.Fatalf("Delete(%v): expected %v, reality: %v", key, key, deleted)
+				}
+				model = append(model[:j], model[j+1:]...)
+			} else if deleted != nil {
+				t.Fatalf("Delete(%v): expected nil, reality: %v", key, deleted)
+			}
+			var items []Int
+			tree.Ascend(func(i Item) bool {
+				items = append(items, i.(Int))
+				return true
+			})
+			if tree.Len() != len(model) || !slices.Equal(items, model) {
+				t.Fatalf("after Delete(%v): expected %v, reality: %v (Len %v)", key, model, items, tree.Len())
+			}
+		}
+	}
+}
+
+// pathKey is a composite key with long common prefixes,
+// so that comparisons are the main cost of tree operations.
+type pathKey struct {
+	dir, name string
+}
+
+func (k pathKey) Less(than Item) bool {
+	o := than.(pathKey)
+	if k.dir != o.dir {
+		return k.dir < o.dir
 	}
-	if tree.root.NDescendants != 21 {
-		t.Errorf("tree.root.NDescendants: r: %v, e: %v", tree.root.NDescendants, 21)
+	return k.name < o.name
+}
+
+// comparedPathKey is a pathKey that implements Comparer.
+type comparedPathKey struct {
+	pathKey
+}
+
+func (k comparedPathKey) Less(than Item) bool {
+	return k.pathKey.Less(than.(comparedPathKey).pathKey)
+}
+
+func (k comparedPathKey) Compare(than Item) int {
+	o := than.(comparedPathKey)
+	if c := strings.Compare(k.dir, o.dir); c != 0 {
+		return c
 	}
-	if r, _ := tree.GetRankOf(Int(25)); r != 21 {
-		t.Errorf("RankOf(Int(25)): r: %v, e: %v", r, 21)
+	return strings.Compare(k.name, o.name)
+}
+
+func newPathKey(i int) pathKey {
+	return pathKey{
+		dir:  fmt.Sprintf("/var/lib/service/shards/%04d", i%100),
+		name: fmt.Sprintf("segment-%08d", i),
 	}
-	if r, _ := tree.GetRankOf(Int(4)); r != 3 {
-		t.Errorf("RankOf(Int(4)): r: %v, e: %v", r, 3)
+}
+
+func TestComparer(t *testing.T) {
+	plain, compared := New(), New()
+	n := 2000
+	for _, i := range rand.Perm(n) {
+		plain.InsertNoReplace(newPathKey(i))
+		compared.InsertNoReplace(comparedPathKey{newPathKey(i)})
+		plain.ReplaceOrInsert(newPathKey(i / 2))
+		compared.ReplaceOrInsert(comparedPathKey{newPathKey(i / 2)})
+	}
+	for _, i := range rand.Perm(n + 100) {
+		p, c := plain.Get(newPathKey(i)), compared.Get(comparedPathKey{newPathKey(i)})
+		if (p == nil) != (c == nil) || p != nil && p.(pathKey) != c.(comparedPathKey).pathKey {
+			t.Fatalf("Get(%v): %v != %v", i, p, c)
+		}
+		if i%3 == 0 {
+			p, c = plain.Delete(newPathKey(i)), compared.Delete(comparedPathKey{newPathKey(i)})
+			if (p == nil) != (c == nil) {
+				t.Fatalf("Delete(%v): %v != %v", i, p, c)
+			}
+		}
+		if plain.Len() != compared.Len() {
+			t.Fatalf("len: %v != %v", plain.Len(), compared.Len())
+		}
 	}
-	if r, _ := tree.GetRankOf(Int(16)); r != 14 {
-		t.Errorf("RankOf(Int(25)): r: %v, e: %v", r, 14)
+	if compare(Int(1), Inf(1)) != -1 || compare(Inf(1), Int(1)) != 1 ||
+		compare(Inf(-1), Int(1)) != -1 || compare(Int(1), Int(1)) != 0 {
+		t.Error("compare does not order Inf")
+	}
+}
+
+func benchmarkKeys(compared bool) []Item {
+	keys := make([]Item, 100000)
+	for i, k := range rand.Perm(len(keys)) {
+		if compared {
+			keys[i] = comparedPathKey{newPathKey(k)}
+		} else {
+			keys[i] = newPathKey(k)
+		}
 	}
-	if r, _ := tree.GetRankOf(Int(3)); r != 3 {
-		t.Errorf("RankOf(Int(3)): r: %v, e: %v", r, 3)
+	return keys
+}
+
+func BenchmarkComparer_Get(b *testing.B) {
+	for _, c := range []struct {
+		name     string
+		compared bool
+	}{{"Less", false}, {"Compare", true}} {
+		b.Run(c.name, func(b *testing.B) {
+			keys := benchmarkKeys(c.compared)
+			tree := New()
+			tree.ReplaceOrInsertBulk(keys...)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				tree.Get(keys[i%len(keys)])
+			}
+		})
+	}
+}
+
+func BenchmarkComparer_InsertDelete(b *testing.B) {
+	for _, c := range []struct {
+		name     string
+		compared bool
+	}{{"Less", false}, {"Compare", true}} {
+		b.Run(c.name, func(b *testing.B) {
+			keys := benchmarkKeys(c.compared)
+			tree := New()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				k := keys[i%len(keys)]
+				if tree.ReplaceOrInsert(k) != nil {
+					tree.Delete(k)
+				}
+			}
+		})
 	}
 }
@@ -0,0 +1,212 @@
+package llrb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+)
+
+// Codec encodes and decodes the elements of a tree for MarshalBinary and UnmarshalBinary.
+type Codec[T any] interface {
+	// AppendItem appends the encoding of item to dst and returns the extended slice.
+	AppendItem(dst []byte, item T) ([]byte, error)
+	// DecodeItem decodes an element from the start of src and returns it
+	// with the number of bytes it used.
+	DecodeItem(src []byte) (item T, n int, err error)
+}
+
+// SetCodec sets the codec used by MarshalBinary and UnmarshalBinary.
+func (t *Tree[T]) SetCodec(c Codec[T]) {
+	t.codec = c
+}
+
+// ErrCorrupt is returned by UnmarshalBinary when the data is not a valid encoding of a tree.
+var ErrCorrupt = errors.New("llrb: corrupt data")
+
+// The encoding of a tree is:
+//
+//	magic "LLRB", version byte, uvarint Len(),
+//	the nodes in preorder, each one is: a flags byte (nodeBlack, nodeLeft, nodeRight),
+//	uvarint NDescendants, the element encoded by the codec,
+//	then the CRC-32 (IEEE) of all the previous bytes, big-endian.
+const (
+	marshalMagic   = "LLRB"
+	marshalVersion = 1
+)
+
+const (
+	nodeBlack = 1 << iota
+	nodeLeft
+	nodeRight
+)
+
+// MarshalBinary encodes the tree with its codec, see SetCodec.
+// The encoding keeps the shape of the tree, its colors and NDescendants,
+// so UnmarshalBinary does not rebalance it.
+// It implements encoding.BinaryMarshaler.
+func (t *Tree[T]) MarshalBinary() ([]byte, error) {
+	if t.codec == nil {
+		return nil, errors.New("llrb: MarshalBinary without a codec, see SetCodec")
+	}
+	data := append([]byte(marshalMagic), marshalVersion)
+	data = binary.AppendUvarint(data, uint64(t.count))
+	data, err := t.marshal(data, t.root)
+	if err != nil {
+		return nil, err
+	}
+	return binary.BigEndian.AppendUint32(data, crc32.ChecksumIEEE(data)), nil
+}
+
+func (t *Tree[T]) marshal(data []byte, h *TreeNode[T]) ([]byte, error) {
+	if h == nil {
+		return data, nil
+	}
+	var flags byte
+	if h.Black {
+		flags |= nodeBlack
+	}
+	if h.Left != nil {
+		flags |= nodeLeft
+	}
+	if h.Right != nil {
+		flags |= nodeRight
+	}
+	data = append(data, flags)
+	data = binary.AppendUvarint(data, uint64(h.NDescendants))
+	data, err := t.codec.AppendItem(data, h.Item)
+	if err != nil {
+		return nil, err
+	}
+	if data, err = t.marshal(data, h.Left); err != nil {
+		return nil, err
+	}
+	return t.marshal(data, h.Right)
+}
+
+// UnmarshalBinary replaces the elements of the tree with the ones decoded from data
+// with the codec of the tree, see SetCodec. data must have been made by MarshalBinary
+// of a tree that orders its elements the same way. If data is corrupt, the tree is
+// unchanged and the error wraps ErrCorrupt. The decoded tree is checked with Validate
+// before it is installed.
+// It implements encoding.BinaryUnmarshaler.
+func (t *Tree[T]) UnmarshalBinary(data []byte) error {
+	if t.codec == nil {
+		return errors.New("llrb: UnmarshalBinary without a codec, see SetCodec")
+	}
+	if len(data) < len(marshalMagic)+1+4 || string(data[:len(marshalMagic)]) != marshalMagic {
+		return fmt.Errorf("%w: not an encoded tree", ErrCorrupt)
+	}
+	if v := data[len(marshalMagic)]; v != marshalVersion {
+		return fmt.Errorf("%w: unknown version %d", ErrCorrupt, v)
+	}
+	data, sum := data[:len(data)-4], binary.BigEndian.Uint32(data[len(data)-4:])
+	if crc32.ChecksumIEEE(data) != sum {
+		return fmt.Errorf("%w: checksum mismatch", ErrCorrupt)
+	}
+	data = data[len(marshalMagic)+1:]
+	count, n := binary.Uvarint(data)
+	if n <= 0 || count > uint64(len(data)) { // a node takes 2 bytes at least
+		return fmt.Errorf("%w: bad length", ErrCorrupt)
+	}
+	u := unmarshaler[T]{t: t, data: data[n:], nodes: int(count)}
+	var root *TreeNode[T]
+	if count > 0 {
+		var err error
+		if root, err = u.node(1); err != nil {
+			return err
+		}
+	}
+	if len(u.data) != 0 {
+		return fmt.Errorf("%w: %d trailing bytes", ErrCorrupt, len(u.data))
+	}
+	decoded := t.newEmpty()
+	decoded.root, decoded.count = root, int(count)
+	if err := decoded.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	t.root, t.count = root, int(count)
+	return nil
+}
+
+type unmarshaler[T any] struct {
+	t     *Tree[T]
+	data  []byte
+	nodes int // the number of nodes left to decode
+}
+
+// node decodes a node at the given depth and its subtree. The depth is bounded
+// by the height of a valid tree, so that a crafted chain cannot exhaust the stack.
+func (u *unmarshaler[T]) node(depth int) (*TreeNode[T], error) {
+	if u.nodes == 0 || len(u.data) == 0 {
+		return nil, fmt.Errorf("%w: more nodes than Len()", ErrCorrupt)
+	}
+	if depth > maxHeight {
+		return nil, fmt.Errorf("%w: deeper than %d nodes", ErrCorrupt, maxHeight)
+	}
+	u.nodes--
+	flags := u.data[0]
+	size, n := binary.Uvarint(u.data[1:])
+	if n <= 0 {
+		return nil, fmt.Errorf("%w: bad NDescendants", ErrCorrupt)
+	}
+	u.data = u.data[1+n:]
+	item, n, err := u.t.codec.DecodeItem(u.data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	u.data = u.data[n:]
+	h := u.t.newNode(item)
+	h.Black, h.NDescendants = flags&nodeBlack != 0, int(size)
+	if flags&nodeLeft != 0 {
+		if h.Left, err = u.node(depth + 1); err != nil {
+			return nil, err
+		}
+	}
+	if flags&nodeRight != 0 {
+		if h.Right, err = u.node(depth + 1); err != nil {
+			return nil, err
+		}
+	}
+	u.t.aggregate(h)
+	return h, nil
+}
+
+// IntCodec is a Codec for the Items of type Int.
+type IntCodec struct{}
+
+func (IntCodec) AppendItem(dst []byte, item Item) ([]byte, error) {
+	i, ok := item.(Int)
+	if !ok {
+		return nil, fmt.Errorf("llrb: IntCodec cannot encode %T", item)
+	}
+	return binary.AppendVarint(dst, int64(i)), nil
+}
+
+func (IntCodec) DecodeItem(src []byte) (Item, int, error) {
+	i, n := binary.Varint(src)
+	if n <= 0 {
+		return nil, 0, errors.New("bad Int")
+	}
+	return Int(i), n, nil
+}
+
+// StringCodec is a Codec for the Items of type String.
+type StringCodec struct{}
+
+func (StringCodec) AppendItem(dst []byte, item Item) ([]byte, error) {
+	s, ok := item.(String)
+	if !ok {
+		return nil, fmt.Errorf("llrb: StringCodec cannot encode %T", item)
+	}
+	dst = binary.AppendUvarint(dst, uint64(len(s)))
+	return append(dst, s...), nil
+}
+
+func (StringCodec) DecodeItem(src []byte) (Item, int, error) {
+	l, n := binary.Uvarint(src)
+	if n <= 0 || l > uint64(len(src)-n) {
+		return nil, 0, errors.New("bad String")
+	}
+	return String(src[n : n+int(l)]), n + int(l), nil
+}
@@ -0,0 +1,126 @@
+package llrb
+
+import (
+	"encoding"
+	"errors"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var (
+	_ encoding.BinaryMarshaler   = (*LLRB)(nil)
+	_ encoding.BinaryUnmarshaler = (*LLRB)(nil)
+)
+
+func TestMarshalBinary(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 10, 1000} {
+		tree, model := newTestTree(n)
+		tree.SetCodec(IntCodec{})
+		data, err := tree.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		loaded := New()
+		loaded.SetCodec(IntCodec{})
+		if err := loaded.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		checkInvariants(t, loaded, model)
+		if !reflect.DeepEqual(shape(loaded.root), shape(tree.root)) {
+			t.Fatalf("n=%v: the shape of the tree changed", n)
+		}
+	}
+
+	tree := New()
+	tree.InsertNoReplaceBulk(String("b"), String(""), String("a"), String("héllo"))
+	tree.SetCodec(StringCodec{})
+	data, err := tree.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded := New()
+	loaded.SetCodec(StringCodec{})
+	if err := loaded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	var items []Item
+	loaded.Ascend(func(i Item) bool {
+		items = append(items, i)
+		return true
+	})
+	if expected := []Item{String(""), String("a"), String("b"), String("héllo")}; !reflect.DeepEqual(items, expected) {
+		t.Errorf("expected %v, reality %v", expected, items)
+	}
+}
+
+// shape returns the colors and sizes of the nodes of h in preorder.
+func shape(h *Node) []int {
+	if h == nil {
+		return nil
+	}
+	s := []int{h.NDescendants}
+	if h.Black {
+		s[0] = -s[0]
+	}
+	return append(append(s, shape(h.Left)...), shape(h.Right)...)
+}
+
+func TestUnmarshalBinary_Corrupt(t *testing.T) {
+	tree, _ := newTestTree(100)
+	tree.SetCodec(IntCodec{})
+	data, _ := tree.MarshalBinary()
+	for k := 0; k < 1000; k++ {
+		corrupt := append([]byte(nil), data...)
+		switch k % 3 {
+		case 0:
+			corrupt[rand.Intn(len(corrupt))] ^= byte(1 + rand.Intn(255))
+		case 1:
+			corrupt = corrupt[:rand.Intn(len(corrupt))]
+		case 2:
+			corrupt = append(corrupt, byte(rand.Intn(256)))
+		}
+		loaded, _ := newTestTree(10)
+		loaded.SetCodec(IntCodec{})
+		previous := loaded.root
+		if err := loaded.UnmarshalBinary(corrupt); !errors.Is(err, ErrCorrupt) {
+			t.Fatalf("expected ErrCorrupt, reality: %v", err)
+		}
+		if loaded.root != previous || loaded.Len() != 10 {
+			t.Fatal("a failed UnmarshalBinary changed the tree")
+		}
+	}
+
+	// a valid checksum over a tree that breaks the rules
+	tree.root.Left.Black = !tree.root.Left.Black
+	data, _ = tree.MarshalBinary()
+	if err := New().UnmarshalBinary(data); err == nil {
+		t.Error("UnmarshalBinary without a codec must fail")
+	}
+	loaded := New()
+	loaded.SetCodec(IntCodec{})
+	if err := loaded.UnmarshalBinary(data); !errors.Is(err, ErrCorrupt) || loaded.Len() != 0 {
+		t.Errorf("expected ErrCorrupt, reality: %v", err)
+	}
+}
+
+func TestUnmarshalBinary_Deep(t *testing.T) {
+	// a chain of right children, far deeper than a valid tree
+	var root *Node
+	for i := 2 * maxHeight; i > 0; i-- {
+		root = &Node{Item: Int(i), Right: root, Black: true, NDescendants: size(root) + 1}
+	}
+	tree := New()
+	tree.SetCodec(IntCodec{})
+	tree.SetRoot(root)
+	data, err := tree.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded := New()
+	loaded.SetCodec(IntCodec{})
+	if err := loaded.UnmarshalBinary(data); !errors.Is(err, ErrCorrupt) || !strings.Contains(err.Error(), "deeper") {
+		t.Errorf("expected ErrCorrupt for the depth, reality: %v", err)
+	}
+}
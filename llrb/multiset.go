@@ -0,0 +1,61 @@
+package llrb
+
+import "iter"
+
+// The functions in this file support trees used as multisets, where
+// InsertNoReplace stores several elements with the same order.
+// InsertNoReplace puts a new element after the elements equal to it and
+// rotations do not change the in-order sequence, so equal elements form
+// a run of consecutive ranks in insertion order.
+
+// CountEqual returns the number of elements whose order is the same as that of key.
+func (t *Tree[T]) CountEqual(key T) int {
+	return t.CountLessOrEqual(key) - t.CountLess(key)
+}
+
+// GetAll returns all elements whose order is the same as that of key, in ascending rank.
+func (t *Tree[T]) GetAll(key T) []T {
+	var items []T
+	t.AscendEqual(key, func(i T) bool {
+		items = append(items, i)
+		return true
+	})
+	return items
+}
+
+// AscendEqual will call iterator once for each element whose order is the same as that of key,
+// in ascending rank. It will stop whenever the iterator returns false.
+func (t *Tree[T]) AscendEqual(key T, iterator func(i T) bool) {
+	t.ascend(inclusive(key), inclusive(key), unrankedIterator(iterator))
+}
+
+// AscendEqualSeq is the iterator form of AscendEqual.
+func (t *Tree[T]) AscendEqualSeq(key T) iter.Seq[T] {
+	return unranked(t.AscendEqualRanked(key))
+}
+
+// AscendEqualRanked is AscendEqualSeq that also yields ranks.
+func (t *Tree[T]) AscendEqualRanked(key T) iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		t.ascend(inclusive(key), inclusive(key), yield)
+	}
+}
+
+// DeleteExact deletes from the tree the first element whose order is the same
+// as that of item and for which eq(element, item) is true, and returns it.
+// ok is false if there is no such element.
+// It costs O(log n + k) where k is the number of elements equal to item.
+func (t *Tree[T]) DeleteExact(item T, eq func(a, b T) bool) (deleted T, ok bool) {
+	rank := 0
+	t.ascend(inclusive(item), inclusive(item), func(r int, i T) bool {
+		if eq(i, item) {
+			rank = r
+			return false
+		}
+		return true
+	})
+	if rank == 0 {
+		return deleted, false
+	}
+	return t.DeleteByRank(rank)
+}
@@ -0,0 +1,79 @@
+package llrb
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// event is ordered by at only, id tells apart events with the same order.
+type event struct {
+	at, id int
+}
+
+func (e event) Less(than Item) bool {
+	return e.at < than.(event).at
+}
+
+func sameEvent(a, b Item) bool { return a.(event).id == b.(event).id }
+
+func TestMultiset(t *testing.T) {
+	tree := New()
+	n := 1000
+	events := make(map[int][]event) // by at, in no particular order
+	for id := 0; id < n; id++ {
+		e := event{at: rand.Intn(50), id: id}
+		tree.InsertNoReplace(e)
+		events[e.at] = append(events[e.at], e)
+	}
+	for _, id := range rand.Perm(n) {
+		at := -1
+		for k, es := range events {
+			for _, e := range es {
+				if e.id == id {
+					at = k
+				}
+			}
+		}
+		key := event{at: at}
+		if c := tree.CountEqual(key); c != len(events[at]) {
+			t.Fatalf("CountEqual(%v): expected %v, reality: %v", at, len(events[at]), c)
+		}
+		all := tree.GetAll(key)
+		var seq []Item
+		for r, e := range tree.AscendEqualRanked(key) {
+			if e.(event).at != at || r != tree.LowerBoundRank(key)+len(seq)+1 {
+				t.Fatalf("AscendEqualRanked(%v): unexpected %v at rank %v", at, e, r)
+			}
+			if len(seq) > 0 && seq[len(seq)-1].(event).id > e.(event).id {
+				t.Fatalf("AscendEqualRanked(%v): events are not in insertion order", at)
+			}
+			seq = append(seq, e)
+		}
+		if len(all) != len(events[at]) || !reflect.DeepEqual(all, seq) {
+			t.Fatalf("GetAll(%v): expected %v items, reality: %v, %v", at, len(events[at]), all, seq)
+		}
+
+		if tree.DeleteExact(event{at: at, id: -1}, sameEvent) != nil {
+			t.Fatalf("DeleteExact deleted a missing event")
+		}
+		deleted := tree.DeleteExact(event{at: at, id: id}, sameEvent)
+		if deleted != (event{at: at, id: id}) {
+			t.Fatalf("DeleteExact(%v, %v): reality: %v", at, id, deleted)
+		}
+		for i, e := range events[at] {
+			if e.id == id {
+				events[at] = append(events[at][:i], events[at][i+1:]...)
+				break
+			}
+		}
+		for _, e := range tree.GetAll(key) {
+			if e.(event).id == id {
+				t.Fatalf("DeleteExact(%v, %v) left the event in the tree", at, id)
+			}
+		}
+	}
+	if tree.Len() != 0 {
+		t.Errorf("expected an empty tree, len: %v", tree.Len())
+	}
+}
@@ -0,0 +1,47 @@
+package llrb
+
+// Mode is the balancing variant of a tree, see NewWithOptions.
+type Mode int
+
+const (
+	// Mode23 keeps the tree a 2-3 tree: a node has at most one red link, on its left.
+	// It is the default and gives the lowest trees.
+	Mode23 Mode = iota
+	// Mode234 keeps the tree a 2-3-4 tree: a black node may also have two red children
+	// (a 4-node), which is split on the way down of the next insertion through it.
+	// Insertions rotate less, the tree is a little higher.
+	Mode234
+)
+
+func (m Mode) String() string {
+	switch m {
+	case Mode23:
+		return "Mode23"
+	case Mode234:
+		return "Mode234"
+	}
+	return "Mode(?)"
+}
+
+// An Option configures a new tree, see NewWithOptions and NewTree.
+type Option interface {
+	apply(o *options)
+}
+
+type options struct {
+	mode Mode
+}
+
+func (m Mode) apply(o *options) { o.mode = m }
+
+// setOptions applies opts to t, it must be called before any element is inserted.
+func (t *Tree[T]) setOptions(opts []Option) {
+	var o options
+	for _, opt := range opts {
+		opt.apply(&o)
+	}
+	t.mode = o.mode
+}
+
+// Mode returns the balancing variant of the tree.
+func (t *Tree[T]) Mode() Mode { return t.mode }
@@ -0,0 +1,154 @@
+package llrb
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestMode234(t *testing.T) {
+	for k := 0; k < 100; k++ {
+		tree := NewWithOptions(Mode234)
+		var model []Int
+		insert := func(key Int) {
+			i := sort.Search(len(model), func(i int) bool { return model[i] > key })
+			model = append(model[:i], append([]Int{key}, model[i:]...)...)
+		}
+		for op := 0; op < 500; op++ {
+			key := Int(rand.Intn(300))
+			i := sort.Search(len(model), func(i int) bool { return model[i] >= key })
+			switch rand.Intn(10) {
+			case 0, 1, 2:
+				tree.InsertNoReplace(key)
+				insert(key)
+			case 3, 4:
+				if tree.ReplaceOrInsert(key) == nil {
+					insert(key)
+				}
+			case 5:
+				if tree.Delete(key) != nil {
+					model = append(model[:i], model[i+1:]...)
+				}
+			case 6:
+				if tree.DeleteMin() != nil {
+					model = model[1:]
+				}
+			case 7:
+				if tree.DeleteMax() != nil {
+					model = model[:len(model)-1]
+				}
+			case 8:
+				if r := 1 + rand.Intn(len(model)+1); tree.DeleteByRank(r) != nil {
+					model = append(model[:r-1], model[r:]...)
+				}
+			case 9:
+				left, right := tree.Clone().Split(key)
+				if left.Mode() != Mode234 || right.Mode() != Mode234 {
+					t.Fatal("Split must keep the mode")
+				}
+				checkInvariants(t, left, model[:i])
+				checkInvariants(t, right, model[i:])
+				tree = Join(left, right)
+			}
+			checkInvariants(t, tree, model)
+		}
+	}
+}
+
+func TestMode234_Validate(t *testing.T) {
+	tree := NewWithOptions(Mode234)
+	tree.InsertNoReplaceBulk(Int(1), Int(2), Int(3))
+	if tree.root.Left == nil || !isRed(tree.root.Left) || !isRed(tree.root.Right) {
+		t.Fatalf("expected a 4-node, reality:\n%v", tree.Pretty())
+	}
+	tree.root.Left.Black = true
+	tree.root.Left, tree.root.Right = tree.root.Right, tree.root.Left
+	tree.root.Left.Item, tree.root.Right.Item = tree.root.Right.Item, tree.root.Left.Item
+	if err := tree.Validate(); err == nil {
+		t.Error("a red right link with a black left one must be invalid")
+	}
+}
+
+// rotationCounter is an Augmenter that counts the rotations of the tree it augments:
+// a rotation makes a node the parent of its parent, then updates both nodes.
+// In Mode234 it also counts the links that fixUp234 reverses when it rebuilds a cluster,
+// the work of a rotation each.
+type rotationCounter struct {
+	parent    map[*Node]*Node
+	rotations int
+}
+
+func newRotationCounter() *rotationCounter {
+	return &rotationCounter{parent: map[*Node]*Node{}}
+}
+
+func (c *rotationCounter) update(h *Node) {
+	for _, child := range [...]*Node{h.Left, h.Right} {
+		if child != nil {
+			if c.parent[h] == child { // h took the place of child
+				c.rotations++
+				c.parent[h] = c.parent[child]
+			}
+			c.parent[child] = h
+		}
+	}
+}
+
+func (c *rotationCounter) detach(*Node) {}
+
+func TestRotationCounter(t *testing.T) {
+	tree := New()
+	c := newRotationCounter()
+	tree.SetAugment(c)
+	tree.InsertNoReplaceBulk(Int(1), Int(2)) // 2 leans right
+	if c.rotations != 1 {
+		t.Errorf("expected 1 rotation, reality: %v", c.rotations)
+	}
+	tree.InsertNoReplace(Int(3)) // a flip
+	tree.InsertNoReplace(Int(0))
+	if c.rotations != 1 {
+		t.Errorf("expected 1 rotation, reality: %v", c.rotations)
+	}
+	tree.Delete(Int(2))
+	checkInvariants(t, tree, []Int{0, 1, 3})
+}
+
+// benchmarkMode inserts b.N random keys into a tree of the given mode then deletes them,
+// and reports the rotations per operation and the average height of the elements.
+// The rotations are counted by replaying the operations on a tree with a rotationCounter,
+// outside of the timed part.
+func benchmarkMode(b *testing.B, mode Mode) {
+	inserts, deletes := rand.Perm(b.N), rand.Perm(b.N)
+	tree := NewWithOptions(mode)
+	b.ResetTimer()
+	for _, k := range inserts {
+		tree.InsertNoReplace(Int(k))
+	}
+	b.StopTimer()
+	avg, _ := tree.HeightStats()
+	b.ReportMetric(avg, "avg-height")
+	b.StartTimer()
+	for _, k := range deletes {
+		tree.Delete(Int(k))
+	}
+	b.StopTimer()
+
+	replay, c := NewWithOptions(mode), newRotationCounter()
+	replay.SetAugment(c)
+	for _, k := range inserts {
+		replay.InsertNoReplace(Int(k))
+	}
+	b.ReportMetric(float64(c.rotations)/float64(b.N), "insert-rotations/op")
+	c.rotations = 0
+	for _, k := range deletes {
+		replay.Delete(Int(k))
+	}
+	b.ReportMetric(float64(c.rotations)/float64(b.N), "delete-rotations/op")
+}
+
+func BenchmarkMode(b *testing.B) {
+	for _, mode := range []Mode{Mode23, Mode234} {
+		b.Run(fmt.Sprint(mode), func(b *testing.B) { benchmarkMode(b, mode) })
+	}
+}
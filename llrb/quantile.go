@@ -0,0 +1,166 @@
+package llrb
+
+import (
+	"math"
+	"slices"
+)
+
+// QuantileMethod selects the element of rank r, among n elements, that is the q-quantile.
+// All methods give the minimum for q = 0 and the maximum for q = 1.
+// For the linear interpolation between two elements, see QuantileLinear.
+type QuantileMethod int
+
+const (
+	// QuantileNearestRank is the smallest element such that a fraction q of the elements
+	// are less or equal to it: r = ceil(q*n), at least 1.
+	QuantileNearestRank QuantileMethod = iota
+	// QuantileLower is the lower of the two elements QuantileLinear interpolates:
+	// r = 1 + floor(q*(n-1)).
+	QuantileLower
+	// QuantileHigher is the higher of them: r = 1 + ceil(q*(n-1)).
+	QuantileHigher
+	// QuantileNearest is the nearest of them, the higher one if they are as near:
+	// r = 1 + round(q*(n-1)).
+	QuantileNearest
+)
+
+func (m QuantileMethod) String() string {
+	switch m {
+	case QuantileNearestRank:
+		return "QuantileNearestRank"
+	case QuantileLower:
+		return "QuantileLower"
+	case QuantileHigher:
+		return "QuantileHigher"
+	case QuantileNearest:
+		return "QuantileNearest"
+	}
+	return "QuantileMethod(?)"
+}
+
+// snap rounds x to the closest integer if it is that integer up to the rounding of
+// float64 arithmetic, so that 0.3 of 10 elements is 3 elements and not 3.0000000000000004.
+func snap(x float64) float64 {
+	if r := math.Round(x); math.Abs(x-r) <= 1e-9*math.Max(1, math.Abs(x)) {
+		return r
+	}
+	return x
+}
+
+// quantileRank returns the rank of the q-quantile of n elements with method m,
+// or 0 if q is not in [0, 1] or n is 0.
+func quantileRank(q float64, n int, m QuantileMethod) int {
+	if !(q >= 0 && q <= 1) || n == 0 {
+		return 0
+	}
+	switch m {
+	case QuantileNearestRank:
+		return max(1, int(math.Ceil(snap(q*float64(n)))))
+	case QuantileLower:
+		return 1 + int(math.Floor(snap(q*float64(n-1))))
+	case QuantileHigher:
+		return 1 + int(math.Ceil(snap(q*float64(n-1))))
+	case QuantileNearest:
+		return 1 + int(math.Floor(snap(q*float64(n-1))+0.5))
+	}
+	panic("unknown quantile method")
+}
+
+// Quantile returns the q-quantile of the elements, selected with the method m,
+// ok is false if the tree is empty or q is not in [0, 1].
+func (t *Tree[T]) Quantile(q float64, m QuantileMethod) (item T, ok bool) {
+	return t.GetByRank(quantileRank(q, t.count, m))
+}
+
+// Quantiles returns the qs-quantiles of the elements, selected with the method m,
+// in the order of qs. The elements are found in a single descent shared by all qs,
+// so k quantiles cost less than k calls to Quantile.
+// It returns nil if the tree is empty or one of qs is not in [0, 1].
+func (t *Tree[T]) Quantiles(m QuantileMethod, qs ...float64) []T {
+	ranks := make([]int, len(qs))
+	for i, q := range qs {
+		if ranks[i] = quantileRank(q, t.count, m); ranks[i] == 0 {
+			return nil
+		}
+	}
+	return t.getByRanks(ranks)
+}
+
+// getByRanks returns the elements with the given ranks, which must be in [1, t.Len()],
+// in the order of ranks.
+func (t *Tree[T]) getByRanks(ranks []int) []T {
+	order := make([]int, len(ranks)) // the indices of ranks, by ascending rank
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortFunc(order, func(i, j int) int { return ranks[i] - ranks[j] })
+	sorted := make([]int, len(ranks))
+	for i, o := range order {
+		sorted[i] = ranks[o]
+	}
+	found := make([]T, len(ranks))
+	t.selectRanks(t.root, 0, sorted, found)
+	items := make([]T, len(ranks))
+	for i, o := range order {
+		items[o] = found[i]
+	}
+	return items
+}
+
+// selectRanks sets items[i] to the element with rank ranks[i], for ranks in ascending
+// order that are all in the subtree h, off is the number of elements before h.
+// The paths to the ranks are walked once, from their common part down.
+func (t *Tree[T]) selectRanks(h *TreeNode[T], off int, ranks []int, items []T) {
+	for len(ranks) > 0 {
+		r := off + size(h.Left) + 1
+		i, _ := slices.BinarySearch(ranks, r) // ranks[:i] are in the left subtree
+		j := i
+		for ; j < len(ranks) && ranks[j] == r; j++ {
+			items[j] = h.Item
+		}
+		if i > 0 {
+			t.selectRanks(h.Left, off, ranks[:i], items[:i])
+		}
+		h, off, ranks, items = h.Right, r, ranks[j:], items[j:]
+	}
+}
+
+// Median returns the middle element, or the two middle elements if the tree has
+// an even number of elements: lower and upper are the elements with ranks
+// floor((n+1)/2) and ceil((n+1)/2), they are the same element if n is odd.
+// ok is false if the tree is empty.
+func (t *Tree[T]) Median() (lower, upper T, ok bool) {
+	if t.count == 0 {
+		return lower, upper, false
+	}
+	items := t.getByRanks([]int{(t.count + 1) / 2, t.count/2 + 1})
+	return items[0], items[1], true
+}
+
+// PercentileOf returns the percentage of the elements that are less than key, in [0, 100],
+// from the rank GetRankOf gives to key. It returns 0 if the tree is empty.
+func (t *Tree[T]) PercentileOf(key T) float64 {
+	if t.count == 0 {
+		return 0
+	}
+	rank, _, _ := t.GetRankOf(key)
+	return 100 * float64(rank-1) / float64(t.count)
+}
+
+// QuantileLinear returns the q-quantile of the values of the elements of t, interpolated
+// linearly between the two elements of ranks 1 + floor(h) and 1 + ceil(h), with h = q*(n-1).
+// It is the common definition of spreadsheets (PERCENTILE.INC) and of NumPy,
+// value returns the value of an element. ok is false if t is empty or q is not in [0, 1].
+func QuantileLinear[T any](t *Tree[T], q float64, value func(item T) float64) (v float64, ok bool) {
+	lo, hi := quantileRank(q, t.count, QuantileLower), quantileRank(q, t.count, QuantileHigher)
+	if lo == 0 {
+		return 0, false
+	}
+	items := t.getByRanks([]int{lo, hi})
+	x := value(items[0])
+	if lo == hi {
+		return x, true
+	}
+	frac := snap(q*float64(t.count-1)) - float64(lo-1)
+	return x + frac*(value(items[1])-x), true
+}
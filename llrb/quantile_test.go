@@ -0,0 +1,127 @@
+package llrb
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// treeOf returns a tree of 10, 20, ..., 10*n.
+func treeOf(n int) *Tree[int] {
+	tree := NewTree(lessInt)
+	for i := 1; i <= n; i++ {
+		tree.InsertNoReplace(10 * i)
+	}
+	return tree
+}
+
+func TestQuantile(t *testing.T) {
+	tree := treeOf(10)
+	for _, c := range []struct {
+		q    float64
+		m    QuantileMethod
+		want int
+	}{
+		{0, QuantileNearestRank, 10},
+		{0.05, QuantileNearestRank, 10},
+		{0.1, QuantileNearestRank, 10},
+		{0.3, QuantileNearestRank, 30}, // 0.3*10 is 3.0000000000000004
+		{0.31, QuantileNearestRank, 40},
+		{0.5, QuantileNearestRank, 50},
+		{1, QuantileNearestRank, 100},
+		{0, QuantileLower, 10},
+		{0.5, QuantileLower, 50},
+		{0.5, QuantileHigher, 60},
+		{0.5, QuantileNearest, 60},
+		{0.3, QuantileNearest, 40}, // 0.3*9 is 2.7
+		{0.45, QuantileNearest, 50},
+		{0.7, QuantileLower, 70}, // 0.7*9 is 6.3
+		{0.7, QuantileHigher, 80},
+		{1, QuantileLower, 100},
+		{1, QuantileHigher, 100},
+	} {
+		if got, ok := tree.Quantile(c.q, c.m); !ok || got != c.want {
+			t.Errorf("Quantile(%v, %v): %v, %v, expected %v", c.q, c.m, got, ok, c.want)
+		}
+	}
+	for _, q := range []float64{-0.1, 1.1, math.NaN()} {
+		if _, ok := tree.Quantile(q, QuantileNearestRank); ok {
+			t.Errorf("Quantile(%v) is ok", q)
+		}
+	}
+	if _, ok := NewTree(lessInt).Quantile(0.5, QuantileNearestRank); ok {
+		t.Error("Quantile of an empty tree is ok")
+	}
+	if got := New().Quantile(0.5, QuantileNearestRank); got != nil {
+		t.Errorf("Quantile of an empty LLRB: %v", got)
+	}
+}
+
+func TestQuantiles(t *testing.T) {
+	for n := 1; n <= 40; n++ {
+		tree := treeOf(n)
+		qs := make([]float64, 1+rand.Intn(10))
+		for i := range qs {
+			qs[i] = float64(rand.Intn(21)) / 20 // with duplicates
+		}
+		for _, m := range []QuantileMethod{QuantileNearestRank, QuantileLower, QuantileHigher, QuantileNearest} {
+			got := tree.Quantiles(m, qs...)
+			want := make([]int, len(qs))
+			for i, q := range qs {
+				want[i], _ = tree.Quantile(q, m)
+			}
+			if !slices.Equal(got, want) {
+				t.Fatalf("n %d: Quantiles(%v, %v): %v, expected %v", n, m, qs, got, want)
+			}
+		}
+	}
+	if got := treeOf(5).Quantiles(QuantileLower, 0.5, 2); got != nil {
+		t.Errorf("Quantiles with q out of [0, 1]: %v", got)
+	}
+}
+
+func TestMedian(t *testing.T) {
+	if lower, upper, ok := treeOf(5).Median(); !ok || lower != 30 || upper != 30 {
+		t.Errorf("Median of 5: %v, %v, %v", lower, upper, ok)
+	}
+	if lower, upper, ok := treeOf(6).Median(); !ok || lower != 30 || upper != 40 {
+		t.Errorf("Median of 6: %v, %v, %v", lower, upper, ok)
+	}
+	if _, _, ok := treeOf(0).Median(); ok {
+		t.Error("Median of an empty tree is ok")
+	}
+	if lower, upper := New().Median(); lower != nil || upper != nil {
+		t.Errorf("Median of an empty LLRB: %v, %v", lower, upper)
+	}
+}
+
+func TestPercentileOf(t *testing.T) {
+	tree := treeOf(4)
+	for _, c := range []struct {
+		key  int
+		want float64
+	}{{5, 0}, {10, 0}, {15, 25}, {30, 50}, {40, 75}, {45, 100}} {
+		if got := tree.PercentileOf(c.key); got != c.want {
+			t.Errorf("PercentileOf(%v): %v, expected %v", c.key, got, c.want)
+		}
+	}
+	if got := treeOf(0).PercentileOf(1); got != 0 {
+		t.Errorf("PercentileOf in an empty tree: %v", got)
+	}
+}
+
+func TestQuantileLinear(t *testing.T) {
+	tree := treeOf(4)
+	value := func(i int) float64 { return float64(i) }
+	for _, c := range []struct {
+		q, want float64
+	}{{0, 10}, {0.25, 17.5}, {0.5, 25}, {0.75, 32.5}, {1, 40}, {1.0 / 3, 20}} {
+		if got, ok := QuantileLinear(tree, c.q, value); !ok || math.Abs(got-c.want) > 1e-9 {
+			t.Errorf("QuantileLinear(%v): %v, %v, expected %v", c.q, got, ok, c.want)
+		}
+	}
+	if _, ok := QuantileLinear(treeOf(0), 0.5, value); ok {
+		t.Error("QuantileLinear of an empty tree is ok")
+	}
+}
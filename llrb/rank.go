@@ -0,0 +1,103 @@
+package llrb
+
+// CountLess returns the number of elements less than key, in O(log n).
+func (t *Tree[T]) CountLess(key T) int {
+	n := 0
+	for h := t.root; h != nil; {
+		if t.less(h.Item, key) {
+			n += size(h.Left) + 1
+			h = h.Right
+		} else {
+			h = h.Left
+		}
+	}
+	return n
+}
+
+// CountLessOrEqual returns the number of elements less or equal to key, in O(log n).
+func (t *Tree[T]) CountLessOrEqual(key T) int {
+	n := 0
+	for h := t.root; h != nil; {
+		if !t.less(key, h.Item) {
+			n += size(h.Left) + 1
+			h = h.Right
+		} else {
+			h = h.Left
+		}
+	}
+	return n
+}
+
+// CountRange returns the number of elements greater or equal to greaterOrEqual
+// and less than lessThan, in O(log n). It is exact for missing keys
+// and counts every duplicate added by InsertNoReplace.
+func (t *Tree[T]) CountRange(greaterOrEqual, lessThan T) int {
+	if n := t.CountLess(lessThan) - t.CountLess(greaterOrEqual); n > 0 {
+		return n
+	}
+	return 0
+}
+
+// LowerBoundRank returns the number of elements strictly less than key.
+// It is the index sort.Search would return for the first element
+// greater or equal to key in the sorted contents of the tree.
+func (t *Tree[T]) LowerBoundRank(key T) int { return t.CountLess(key) }
+
+// UpperBoundRank returns the number of elements less or equal to key.
+// It is the index sort.Search would return for the first element
+// greater than key in the sorted contents of the tree.
+func (t *Tree[T]) UpperBoundRank(key T) int { return t.CountLessOrEqual(key) }
+
+// Floor returns the greatest element less or equal to key and its rank,
+// the last one of them if there are several. ok is false if there is no such element.
+func (t *Tree[T]) Floor(key T) (item T, rank int, ok bool) {
+	return t.lastBelow(inclusive(key))
+}
+
+// Lower returns the greatest element strictly less than key and its rank,
+// the last one of them if there are several. ok is false if there is no such element.
+func (t *Tree[T]) Lower(key T) (item T, rank int, ok bool) {
+	return t.lastBelow(exclusive(key))
+}
+
+// Ceiling returns the least element greater or equal to key and its rank,
+// the first one of them if there are several. ok is false if there is no such element.
+func (t *Tree[T]) Ceiling(key T) (item T, rank int, ok bool) {
+	return t.firstAbove(inclusive(key))
+}
+
+// Higher returns the least element strictly greater than key and its rank,
+// the first one of them if there are several. ok is false if there is no such element.
+func (t *Tree[T]) Higher(key T) (item T, rank int, ok bool) {
+	return t.firstAbove(exclusive(key))
+}
+
+// lastBelow finds the last element on the inner side of hi in one descent.
+func (t *Tree[T]) lastBelow(hi bound[T]) (item T, rank int, ok bool) {
+	off := 0
+	for h := t.root; h != nil; {
+		if t.below(h.Item, hi) {
+			off += size(h.Left) + 1
+			item, rank, ok = h.Item, off, true
+			h = h.Right
+		} else {
+			h = h.Left
+		}
+	}
+	return item, rank, ok
+}
+
+// firstAbove finds the first element on the inner side of lo in one descent.
+func (t *Tree[T]) firstAbove(lo bound[T]) (item T, rank int, ok bool) {
+	off := 0
+	for h := t.root; h != nil; {
+		if t.above(h.Item, lo) {
+			item, rank, ok = h.Item, off+size(h.Left)+1, true
+			h = h.Left
+		} else {
+			off += size(h.Left) + 1
+			h = h.Right
+		}
+	}
+	return item, rank, ok
+}
@@ -0,0 +1,87 @@
+package llrb
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestCount(t *testing.T) {
+	tree, model := newTestTree(300)
+	countLess := func(key Int) int {
+		return sort.Search(len(model), func(i int) bool { return model[i] >= key })
+	}
+	countLessOrEqual := func(key Int) int {
+		return sort.Search(len(model), func(i int) bool { return model[i] > key })
+	}
+	for key := Int(-2); key <= 602; key++ {
+		if r, e := tree.CountLess(key), countLess(key); r != e {
+			t.Errorf("CountLess(%v): expected %v, reality: %v", key, e, r)
+		}
+		if r, e := tree.CountLessOrEqual(key), countLessOrEqual(key); r != e {
+			t.Errorf("CountLessOrEqual(%v): expected %v, reality: %v", key, e, r)
+		}
+	}
+	for k := 0; k < 1000; k++ {
+		lo, hi := Int(rand.Intn(700)-50), Int(rand.Intn(700)-50)
+		e := 0
+		for _, i := range model {
+			if lo <= i && i < hi {
+				e++
+			}
+		}
+		if r := tree.CountRange(lo, hi); r != e {
+			t.Errorf("CountRange(%v, %v): expected %v, reality: %v", lo, hi, e, r)
+		}
+	}
+	if r := tree.CountRange(Inf(-1), Inf(1)); r != len(model) {
+		t.Errorf("CountRange(-Inf, +Inf): expected %v, reality: %v", len(model), r)
+	}
+}
+
+func TestBoundRank(t *testing.T) {
+	tree, model := newTestTree(300)
+	for key := Int(-2); key <= 602; key++ {
+		lower := sort.Search(len(model), func(i int) bool { return model[i] >= key })
+		upper := sort.Search(len(model), func(i int) bool { return model[i] > key })
+		if r := tree.LowerBoundRank(key); r != lower {
+			t.Errorf("LowerBoundRank(%v): expected %v, reality: %v", key, lower, r)
+		}
+		if r := tree.UpperBoundRank(key); r != upper {
+			t.Errorf("UpperBoundRank(%v): expected %v, reality: %v", key, upper, r)
+		}
+		r, item := tree.GetRankOf(key)
+		if r != lower+1 || (item != nil) != (lower < upper) {
+			t.Errorf("GetRankOf(%v): expected rank %v, found %v, reality: %v, %v",
+				key, lower+1, lower < upper, r, item)
+		}
+	}
+}
+
+func TestNeighbours(t *testing.T) {
+	tree, model := newTestTree(300)
+	for key := Int(-2); key <= 602; key++ {
+		lower := sort.Search(len(model), func(i int) bool { return model[i] >= key })
+		upper := sort.Search(len(model), func(i int) bool { return model[i] > key })
+		for _, c := range []struct {
+			name  string
+			query func(Item) (Item, int)
+			rank  int // expected rank, out of [1, len(model)] if there is no such item
+		}{
+			{"Floor", tree.Floor, upper},
+			{"Lower", tree.Lower, lower},
+			{"Ceiling", tree.Ceiling, lower + 1},
+			{"Higher", tree.Higher, upper + 1},
+		} {
+			var expected Item
+			expectedRank := 0
+			if c.rank >= 1 && c.rank <= len(model) {
+				expected, expectedRank = model[c.rank-1], c.rank
+			}
+			if item, r := c.query(key); item != expected || r != expectedRank {
+				t.Errorf("%v(%v): expected %v at rank %v, reality: %v at rank %v",
+					c.name, key, expected, expectedRank, item, r)
+			}
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package llrb
+
+import "iter"
+
+// The functions in this file return range-over-func iterators.
+// Each callback walk X of iterator.go has an iterator XSeq, and XRanked that
+// also yields the rank of each element (rank start from 1). All, Backward,
+// Range and RangeByRank are short names for the most common ones.
+// A loop over the iterator can break early and the walk stops cleanly.
+// As with the callback walks, the tree must not be modified during the loop.
+
+// unranked drops the rank of the elements yielded by seq.
+func unranked[T any](seq iter.Seq2[int, T]) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		seq(func(_ int, i T) bool { return yield(i) })
+	}
+}
+
+// All returns an iterator over all elements in ascending order, it is AscendSeq.
+func (t *Tree[T]) All() iter.Seq[T] { return t.AscendSeq() }
+
+// Backward returns an iterator over all elements in descending order, it is DescendSeq.
+func (t *Tree[T]) Backward() iter.Seq[T] { return t.DescendSeq() }
+
+// Range returns an iterator over the elements in [greaterOrEqual, lessThan)
+// in ascending order, it is AscendRangeSeq.
+func (t *Tree[T]) Range(greaterOrEqual, lessThan T) iter.Seq[T] {
+	return t.AscendRangeSeq(greaterOrEqual, lessThan)
+}
+
+// RangeByRank returns an iterator over the elements with rank in [from, to)
+// in ascending order, it is AscendRankRangeSeq.
+func (t *Tree[T]) RangeByRank(from, to int) iter.Seq[T] {
+	return t.AscendRankRangeSeq(from, to)
+}
+
+// AscendSeq is the iterator form of Ascend.
+func (t *Tree[T]) AscendSeq() iter.Seq[T] { return unranked(t.AscendRanked()) }
+
+// AscendRanked is AscendSeq that also yields ranks.
+func (t *Tree[T]) AscendRanked() iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		t.ascend(bound[T]{}, bound[T]{}, yield)
+	}
+}
+
+// DescendSeq is the iterator form of Descend.
+func (t *Tree[T]) DescendSeq() iter.Seq[T] { return unranked(t.DescendRanked()) }
+
+// DescendRanked is DescendSeq that also yields ranks.
+func (t *Tree[T]) DescendRanked() iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		t.descend(bound[T]{}, bound[T]{}, yield)
+	}
+}
+
+// AscendRangeSeq is the iterator form of AscendRange.
+func (t *Tree[T]) AscendRangeSeq(greaterOrEqual, lessThan T) iter.Seq[T] {
+	return unranked(t.AscendRangeRanked(greaterOrEqual, lessThan))
+}
+
+// AscendRangeRanked is AscendRangeSeq that also yields ranks.
+func (t *Tree[T]) AscendRangeRanked(greaterOrEqual, lessThan T) iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		t.ascend(inclusive(greaterOrEqual), exclusive(lessThan), yield)
+	}
+}
+
+// AscendRankRangeSeq is the iterator form of AscendRankRange.
+func (t *Tree[T]) AscendRankRangeSeq(from, to int) iter.Seq[T] {
+	return unranked(t.AscendRankRangeRanked(from, to))
+}
+
+// AscendRankRangeRanked is AscendRankRangeSeq that also yields ranks.
+func (t *Tree[T]) AscendRankRangeRanked(from, to int) iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		t.ascendRank(from, to, yield)
+	}
+}
+
+// DescendRankRangeSeq is the iterator form of DescendRankRange.
+func (t *Tree[T]) DescendRankRangeSeq(from, to int) iter.Seq[T] {
+	return unranked(t.DescendRankRangeRanked(from, to))
+}
+
+// DescendRankRangeRanked is DescendRankRangeSeq that also yields ranks.
+func (t *Tree[T]) DescendRankRangeRanked(from, to int) iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		t.descendRank(from, to, yield)
+	}
+}
+
+// AscendGreaterOrEqualSeq is the iterator form of AscendGreaterOrEqual.
+func (t *Tree[T]) AscendGreaterOrEqualSeq(pivot T) iter.Seq[T] {
+	return unranked(t.AscendGreaterOrEqualRanked(pivot))
+}
+
+// AscendGreaterOrEqualRanked is AscendGreaterOrEqualSeq that also yields ranks.
+func (t *Tree[T]) AscendGreaterOrEqualRanked(pivot T) iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		t.ascend(inclusive(pivot), bound[T]{}, yield)
+	}
+}
+
+// AscendLessThanSeq is the iterator form of AscendLessThan.
+func (t *Tree[T]) AscendLessThanSeq(pivot T) iter.Seq[T] {
+	return unranked(t.AscendLessThanRanked(pivot))
+}
+
+// AscendLessThanRanked is AscendLessThanSeq that also yields ranks.
+func (t *Tree[T]) AscendLessThanRanked(pivot T) iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		t.ascend(bound[T]{}, exclusive(pivot), yield)
+	}
+}
+
+// DescendLessOrEqualSeq is the iterator form of DescendLessOrEqual.
+func (t *Tree[T]) DescendLessOrEqualSeq(pivot T) iter.Seq[T] {
+	return unranked(t.DescendLessOrEqualRanked(pivot))
+}
+
+// DescendLessOrEqualRanked is DescendLessOrEqualSeq that also yields ranks.
+func (t *Tree[T]) DescendLessOrEqualRanked(pivot T) iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		t.descend(bound[T]{}, inclusive(pivot), yield)
+	}
+}
+
+// AscendGreaterThanSeq is the iterator form of AscendGreaterThan.
+func (t *Tree[T]) AscendGreaterThanSeq(pivot T) iter.Seq[T] {
+	return unranked(t.AscendGreaterThanRanked(pivot))
+}
+
+// AscendGreaterThanRanked is AscendGreaterThanSeq that also yields ranks.
+func (t *Tree[T]) AscendGreaterThanRanked(pivot T) iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		t.ascend(exclusive(pivot), bound[T]{}, yield)
+	}
+}
+
+// AscendLessOrEqualSeq is the iterator form of AscendLessOrEqual.
+func (t *Tree[T]) AscendLessOrEqualSeq(pivot T) iter.Seq[T] {
+	return unranked(t.AscendLessOrEqualRanked(pivot))
+}
+
+// AscendLessOrEqualRanked is AscendLessOrEqualSeq that also yields ranks.
+func (t *Tree[T]) AscendLessOrEqualRanked(pivot T) iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		t.ascend(bound[T]{}, inclusive(pivot), yield)
+	}
+}
+
+// DescendRangeSeq is the iterator form of DescendRange.
+func (t *Tree[T]) DescendRangeSeq(lessOrEqual, greaterThan T) iter.Seq[T] {
+	return unranked(t.DescendRangeRanked(lessOrEqual, greaterThan))
+}
+
+// DescendRangeRanked is DescendRangeSeq that also yields ranks.
+func (t *Tree[T]) DescendRangeRanked(lessOrEqual, greaterThan T) iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		t.descend(exclusive(greaterThan), inclusive(lessOrEqual), yield)
+	}
+}
+
+// DescendLessThanSeq is the iterator form of DescendLessThan.
+func (t *Tree[T]) DescendLessThanSeq(pivot T) iter.Seq[T] {
+	return unranked(t.DescendLessThanRanked(pivot))
+}
+
+// DescendLessThanRanked is DescendLessThanSeq that also yields ranks.
+func (t *Tree[T]) DescendLessThanRanked(pivot T) iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		t.descend(bound[T]{}, exclusive(pivot), yield)
+	}
+}
+
+// DescendGreaterThanSeq is the iterator form of DescendGreaterThan.
+func (t *Tree[T]) DescendGreaterThanSeq(pivot T) iter.Seq[T] {
+	return unranked(t.DescendGreaterThanRanked(pivot))
+}
+
+// DescendGreaterThanRanked is DescendGreaterThanSeq that also yields ranks.
+func (t *Tree[T]) DescendGreaterThanRanked(pivot T) iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		t.descend(exclusive(pivot), bound[T]{}, yield)
+	}
+}
+
+// DescendGreaterOrEqualSeq is the iterator form of DescendGreaterOrEqual.
+func (t *Tree[T]) DescendGreaterOrEqualSeq(pivot T) iter.Seq[T] {
+	return unranked(t.DescendGreaterOrEqualRanked(pivot))
+}
+
+// DescendGreaterOrEqualRanked is DescendGreaterOrEqualSeq that also yields ranks.
+func (t *Tree[T]) DescendGreaterOrEqualRanked(pivot T) iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		t.descend(inclusive(pivot), bound[T]{}, yield)
+	}
+}
@@ -0,0 +1,96 @@
+package llrb
+
+import (
+	"iter"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// collect returns the items and ranks yielded by seq, stopping after limit items if limit >= 0.
+func collect(seq iter.Seq2[int, Item], limit int) (items []Item, ranks []int) {
+	for r, i := range seq {
+		if limit >= 0 && len(items) == limit {
+			break
+		}
+		items = append(items, i)
+		ranks = append(ranks, r)
+	}
+	return items, ranks
+}
+
+func TestSeq(t *testing.T) {
+	tree, model := newTestTree(300)
+	for k := 0; k < 200; k++ {
+		lo, hi := Int(rand.Intn(700)-50), Int(rand.Intn(700)-50)
+		from, to := rand.Intn(len(model)+10)-5, rand.Intn(len(model)+10)-5
+		limit := rand.Intn(len(model)+1) - 1 // -1 means no break
+		for _, c := range []struct {
+			name string
+			seq  iter.Seq2[int, Item]
+			keep func(r int, i Int) bool
+			desc bool
+		}{
+			{"Ascend", tree.AscendRanked(), func(int, Int) bool { return true }, false},
+			{"Descend", tree.DescendRanked(), func(int, Int) bool { return true }, true},
+			{"AscendRange", tree.AscendRangeRanked(lo, hi), func(_ int, i Int) bool { return lo <= i && i < hi }, false},
+			{"AscendRankRange", tree.AscendRankRangeRanked(from, to), func(r int, _ Int) bool { return from <= r && r < to }, false},
+			{"DescendRankRange", tree.DescendRankRangeRanked(to, from), func(r int, _ Int) bool { return from < r && r <= to }, true},
+			{"AscendGreaterOrEqual", tree.AscendGreaterOrEqualRanked(lo), func(_ int, i Int) bool { return lo <= i }, false},
+			{"AscendLessThan", tree.AscendLessThanRanked(hi), func(_ int, i Int) bool { return i < hi }, false},
+			{"DescendLessOrEqual", tree.DescendLessOrEqualRanked(hi), func(_ int, i Int) bool { return i <= hi }, true},
+		} {
+			var expected []Item
+			var expectedRanks []int
+			for j := range model {
+				if c.desc {
+					j = len(model) - 1 - j
+				}
+				if c.keep(j+1, model[j]) && (limit < 0 || len(expected) < limit) {
+					expected = append(expected, model[j])
+					expectedRanks = append(expectedRanks, j+1)
+				}
+			}
+			items, ranks := collect(c.seq, limit)
+			if !reflect.DeepEqual(items, expected) || !reflect.DeepEqual(ranks, expectedRanks) {
+				t.Fatalf("%v(lo=%v, hi=%v, from=%v, to=%v, limit=%v):\nexpected %v %v\nreality  %v %v",
+					c.name, lo, hi, from, to, limit, expected, expectedRanks, items, ranks)
+			}
+		}
+	}
+}
+
+func TestSeq_Unranked(t *testing.T) {
+	tree := New()
+	tree.InsertNoReplaceBulk(Int(4), Int(6), Int(1), Int(3))
+	var ary []Item
+	for i := range tree.All() {
+		ary = append(ary, i)
+	}
+	for i := range tree.Backward() {
+		ary = append(ary, i)
+	}
+	for i := range tree.Range(Int(3), Int(6)) {
+		ary = append(ary, i)
+	}
+	for i := range tree.RangeByRank(2, 4) {
+		ary = append(ary, i)
+	}
+	for i := range tree.AscendGreaterOrEqualSeq(Int(4)) {
+		ary = append(ary, i)
+	}
+	for i := range tree.AscendLessThanSeq(Int(4)) {
+		ary = append(ary, i)
+	}
+	for i := range tree.DescendLessOrEqualSeq(Int(4)) {
+		if i == Int(1) {
+			break
+		}
+		ary = append(ary, i)
+	}
+	expected := []Item{Int(1), Int(3), Int(4), Int(6), Int(6), Int(4), Int(3), Int(1),
+		Int(3), Int(4), Int(3), Int(4), Int(4), Int(6), Int(1), Int(3), Int(4), Int(3)}
+	if !reflect.DeepEqual(ary, expected) {
+		t.Errorf("expected %v but got %v", expected, ary)
+	}
+}
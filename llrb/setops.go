@@ -0,0 +1,83 @@
+package llrb
+
+// The set operations below merge the sorted elements of both trees in O(n + m)
+// and build the result in O(n + m), they do not modify their inputs.
+// Trees with duplicates are treated as multisets: the k-th element of a equal
+// to some key is matched with the k-th element of b equal to it, if there is one.
+// The result orders its elements like a.
+
+// UnionTree returns a tree with the elements of a and b. For each element of a
+// matched with an equal element of b, the result holds resolve(itemOfA, itemOfB)
+// instead of both, or the element of a if resolve is nil.
+func UnionTree[T any](a, b *Tree[T], resolve func(a, b T) T) *Tree[T] {
+	return a.merge(b, func(i T) bool { return true }, func(i, j T) (T, bool) {
+		if resolve == nil {
+			return i, true
+		}
+		return resolve(i, j), true
+	}, func(j T) bool { return true })
+}
+
+// IntersectionTree returns a tree with the elements of a matched with an equal element of b.
+func IntersectionTree[T any](a, b *Tree[T]) *Tree[T] {
+	return a.merge(b, func(i T) bool { return false }, func(i, _ T) (T, bool) {
+		return i, true
+	}, func(j T) bool { return false })
+}
+
+// DifferenceTree returns a tree with the elements of a not matched with an equal element of b.
+func DifferenceTree[T any](a, b *Tree[T]) *Tree[T] {
+	return a.merge(b, func(i T) bool { return true }, func(i, _ T) (T, bool) {
+		return i, false
+	}, func(j T) bool { return false })
+}
+
+// merge merges the elements of t and b in order into a new tree,
+// onlyA and onlyB tell whether to keep an unmatched element of t or b,
+// both tells what to keep for a matched pair.
+func (t *Tree[T]) merge(b *Tree[T], onlyA func(i T) bool, both func(i, j T) (T, bool), onlyB func(j T) bool) *Tree[T] {
+	as, bs := appendInOrder(nil, t.root), appendInOrder(nil, b.root)
+	items := make([]T, 0, len(as)+len(bs))
+	for len(as) > 0 && len(bs) > 0 {
+		switch c := t.compare(as[0], bs[0]); {
+		case c < 0:
+			if onlyA(as[0]) {
+				items = append(items, as[0])
+			}
+			as = as[1:]
+		case c > 0:
+			if onlyB(bs[0]) {
+				items = append(items, bs[0])
+			}
+			bs = bs[1:]
+		default:
+			if i, ok := both(as[0], bs[0]); ok {
+				items = append(items, i)
+			}
+			as, bs = as[1:], bs[1:]
+		}
+	}
+	for _, i := range as {
+		if onlyA(i) {
+			items = append(items, i)
+		}
+	}
+	for _, j := range bs {
+		if onlyB(j) {
+			items = append(items, j)
+		}
+	}
+	r := t.newEmpty()
+	r.root, r.count = r.buildSorted(items), len(items) // the nodes are r's, not t's
+	return r
+}
+
+// appendInOrder appends the elements of the subtree h to dst in ascending order.
+func appendInOrder[T any](dst []T, h *TreeNode[T]) []T {
+	for h != nil {
+		dst = appendInOrder(dst, h.Left)
+		dst = append(dst, h.Item)
+		h = h.Right
+	}
+	return dst
+}
@@ -0,0 +1,98 @@
+package llrb
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// pair is an Item that orders by key only, so that the tests can tell
+// which tree an Item of a set operation comes from.
+type pair struct {
+	key, from int
+}
+
+func (p pair) Less(than Item) bool { return p.key < than.(pair).key }
+
+func TestSetOps(t *testing.T) {
+	for k := 0; k < 300; k++ {
+		a, b := New(), New()
+		n := rand.Intn(1 << uint(rand.Intn(10)))
+		ma, mb := make([]int, n+1), make([]int, n+1) // multiplicity of each key in a and b
+		for i := 0; i < n; i++ {
+			x, y := rand.Intn(n+1), rand.Intn(n+1)
+			a.InsertNoReplace(pair{x, 1})
+			b.InsertNoReplace(pair{y, 2})
+			ma[x]++
+			mb[y]++
+		}
+		var union, intersection, difference []Int
+		for key := 0; key <= n; key++ {
+			both := min(ma[key], mb[key])
+			for i := 0; i < ma[key]+mb[key]-both; i++ {
+				union = append(union, Int(key))
+			}
+			for i := 0; i < both; i++ {
+				intersection = append(intersection, Int(key))
+			}
+			for i := 0; i < ma[key]-both; i++ {
+				difference = append(difference, Int(key))
+			}
+		}
+
+		u := Union(a, b, func(x, y Item) Item {
+			if x.(pair).key != y.(pair).key || x.(pair).from != 1 || y.(pair).from != 2 {
+				t.Fatalf("resolve(%v, %v)", x, y)
+			}
+			return pair{x.(pair).key, 3}
+		})
+		checkSet(t, u, union, 0)
+		checkSet(t, Union(a, b, nil), union, 0)
+		checkSet(t, Intersection(a, b), intersection, 1)
+		checkSet(t, Difference(a, b), difference, 1)
+		if a.Len() != n || b.Len() != n {
+			t.Fatal("the operands must be unchanged")
+		}
+		var got []Int
+		u.Ascend(func(i Item) bool {
+			if i.(pair).from == 3 {
+				got = append(got, Int(i.(pair).key))
+			}
+			return true
+		})
+		if len(got) != len(intersection) {
+			t.Fatalf("expected %v resolved items, reality %v", len(intersection), len(got))
+		}
+	}
+}
+
+// checkSet checks the shape of tree and that its keys are model,
+// if from is not 0 every pair must come from it.
+func checkSet(t *testing.T, tree *LLRB, model []Int, from int) {
+	t.Helper()
+	keys := New()
+	for r := 1; r <= tree.Len(); r++ {
+		p := tree.GetByRank(r).(pair)
+		if from != 0 && p.from != from {
+			t.Fatalf("%v does not come from tree %v", p, from)
+		}
+		keys.InsertNoReplace(Int(p.key))
+	}
+	checkInvariants(t, keys, model)
+	checkShape(t, tree)
+}
+
+func TestBuildSorted(t *testing.T) {
+	for n := 0; n < 300; n++ {
+		var model []Int
+		for i := 0; i < n; i++ {
+			model = append(model, Int(i))
+		}
+		items := make([]Item, n)
+		for i := range model {
+			items[i] = model[i]
+		}
+		tree := New()
+		tree.root, tree.count = tree.buildSorted(items), n
+		checkInvariants(t, tree, model)
+	}
+}
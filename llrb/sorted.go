@@ -0,0 +1,88 @@
+package llrb
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrNotSorted is returned when the items given to build a tree are not in ascending order.
+var ErrNotSorted = errors.New("llrb: items are not sorted")
+
+// AppendSorted inserts items after the elements of the tree in O(k + log n) for k items,
+// instead of O(k log n) for inserting them one by one. items must be in ascending order
+// (duplicates are allowed) and none of them may be less than the maximum of the tree,
+// otherwise the tree is unchanged and an error wrapping ErrNotSorted is returned.
+func (t *Tree[T]) AppendSorted(items []T) error {
+	for i := range items {
+		if i > 0 && t.less(items[i], items[i-1]) {
+			return fmt.Errorf("%w: item %d is less than item %d", ErrNotSorted, i, i-1)
+		}
+	}
+	if last, ok := t.Max(); ok && len(items) > 0 && t.less(items[0], last) {
+		return fmt.Errorf("%w: item 0 is less than the maximum of the tree", ErrNotSorted)
+	}
+	r := t.buildSorted(items)
+	t.root = t.join2(t.root, r)
+	if isRed(t.root) {
+		t.root.Black = true
+	}
+	t.count += len(items)
+	return nil
+}
+
+// buildSorted returns the root of a tree holding items in O(len(items)),
+// items must be sorted. The tree is built as a 2-3 tree whose black height h
+// is the greatest one with 2^h-1 <= len(items), then every subtree of black height h
+// holds between 2^h-1 (all 2-nodes) and 3^h-1 (all 3-nodes) elements.
+func (t *Tree[T]) buildSorted(items []T) *TreeNode[T] {
+	h := 0
+	for n := len(items); n > 0; n >>= 1 {
+		h++
+	}
+	if len(items) < 1<<uint(h)-1 {
+		h--
+	}
+	return t.build(items, h)
+}
+
+// maxSorted returns 3^h-1, the greatest number of elements a subtree of black height h
+// can hold, or math.MaxInt if it is greater.
+func maxSorted(h int) int {
+	n := 1
+	for ; h > 0 && n <= math.MaxInt/3; h-- {
+		n *= 3
+	}
+	if h > 0 {
+		return math.MaxInt
+	}
+	return n - 1
+}
+
+// build returns the root of a subtree with black height h holding items,
+// REQUIRE: 2^h-1 <= len(items) <= 3^h-1
+func (t *Tree[T]) build(items []T, h int) *TreeNode[T] {
+	if len(items) == 0 {
+		return nil
+	}
+	n, hi := len(items), maxSorted(h-1)
+	if n-1-hi <= hi { // a 2-node
+		m := (n - 1) / 2
+		x := t.newNode(items[m])
+		x.Black = true
+		x.Left, x.Right = t.build(items[:m], h-1), t.build(items[m+1:], h-1)
+		t.update(x)
+		return x
+	}
+	// a 3-node, its n-2 descendants are shared evenly between 3 subtrees
+	m1 := (n - 2) / 3
+	m2 := m1 + 1 + (n-2-m1)/2
+	l := t.newNode(items[m1])
+	l.Left, l.Right = t.build(items[:m1], h-1), t.build(items[m1+1:m2], h-1)
+	t.update(l)
+	x := t.newNode(items[m2])
+	x.Black = true
+	x.Left, x.Right = l, t.build(items[m2+1:], h-1)
+	t.update(x)
+	return x
+}
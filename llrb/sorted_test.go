@@ -0,0 +1,81 @@
+package llrb
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+func TestFromSorted(t *testing.T) {
+	for n := 0; n < 500; n++ {
+		_, model := newTestTree(n)
+		items := make([]Item, n)
+		for i := range model {
+			items[i] = model[i]
+		}
+		tree, err := FromSorted(items)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkInvariants(t, tree, model)
+		for i := range model {
+			if item := tree.GetByRank(i + 1); item != model[i] {
+				t.Fatalf("GetByRank(%v): expected %v, reality: %v", i+1, model[i], item)
+			}
+		}
+	}
+
+	if _, err := FromSorted([]Item{Int(1), Int(3), Int(2)}); !errors.Is(err, ErrNotSorted) {
+		t.Errorf("expected ErrNotSorted, reality: %v", err)
+	}
+}
+
+func TestAppendSorted(t *testing.T) {
+	for k := 0; k < 300; k++ {
+		tree, model := newTestTree(rand.Intn(300))
+		var items []Item
+		next := Int(len(model) * 2)
+		for i := rand.Intn(1 << uint(rand.Intn(10))); i > 0; i-- {
+			next += Int(rand.Intn(2))
+			items = append(items, next)
+			model = append(model, next)
+		}
+		if err := tree.AppendSorted(items); err != nil {
+			t.Fatal(err)
+		}
+		checkInvariants(t, tree, model)
+	}
+
+	tree, model := newTestTree(100)
+	for _, items := range [][]Item{
+		{model[len(model)-1] - 1},
+		{model[len(model)-1] + 2, model[len(model)-1] + 1},
+	} {
+		if err := tree.AppendSorted(items); !errors.Is(err, ErrNotSorted) {
+			t.Errorf("AppendSorted(%v): expected ErrNotSorted, reality: %v", items, err)
+		}
+		checkInvariants(t, tree, model)
+	}
+}
+
+func BenchmarkFromSorted(b *testing.B) {
+	items := make([]Item, 1<<20)
+	for i := range items {
+		items[i] = Int(i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		FromSorted(items)
+	}
+}
+
+func BenchmarkInsertNoReplaceBulk(b *testing.B) {
+	items := make([]Item, 1<<20)
+	for i := range items {
+		items[i] = Int(i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		New().InsertNoReplaceBulk(items...)
+	}
+}
@@ -0,0 +1,178 @@
+package llrb
+
+// Split and join work on black heights: the black height of a node is the number
+// of black nodes on every path from it down to a leaf, itself included.
+// Joining two trees of black heights hl and hr costs O(|hl-hr| + 1),
+// and the joins made by a split add up to O(log n).
+
+// Split moves the elements less than key to left and the others to right, in O(log n).
+// t is empty afterwards, left and right order their elements like t.
+func (t *Tree[T]) Split(key T) (left, right *Tree[T]) {
+	return t.split(func(h *TreeNode[T], _ int) bool { return t.less(h.Item, key) })
+}
+
+// SplitAtRank moves the elements with rank less than r to left and the others
+// to right, in O(log n), so the element with rank r becomes the minimum of right.
+// t is empty afterwards, left and right order their elements like t.
+func (t *Tree[T]) SplitAtRank(r int) (left, right *Tree[T]) {
+	return t.split(func(_ *TreeNode[T], rank int) bool { return rank < r })
+}
+
+func (t *Tree[T]) split(toLeft func(h *TreeNode[T], rank int) bool) (left, right *Tree[T]) {
+	l, _, r, _ := t.splitSubtree(t.root, blackHeight(t.root), 0, toLeft)
+	left, right = t.newEmpty(), t.newEmpty()
+	// left and right may share nodes with clones of t, so they own none of them
+	left.owner, right.owner = new(owner), new(owner)
+	left.root, left.count = l, size(l)
+	right.root, right.count = r, size(r)
+	t.root, t.count = nil, 0
+	return left, right
+}
+
+// JoinTree returns a tree with the elements of left followed by the elements of right,
+// in O(log n). No element of right may be less than an element of left.
+// left and right must order their elements the same way and have the same augmentation,
+// they are empty afterwards.
+func JoinTree[T any](left, right *Tree[T]) *Tree[T] {
+	if left.augment != right.augment {
+		panic("joining trees with different augmentations")
+	}
+	if lmax, ok := left.Max(); ok {
+		if rmin, ok := right.Min(); ok && left.less(rmin, lmax) {
+			panic("joining overlapping trees")
+		}
+	}
+	t := left.newEmpty()
+	t.owner = new(owner) // the nodes of left and right may be shared, see split
+	t.root = t.join2(left.root, right.root)
+	if isRed(t.root) {
+		t.root.Black = true
+	}
+	t.count = size(t.root)
+	left.root, left.count = nil, 0
+	right.root, right.count = nil, 0
+	return t
+}
+
+// newEmpty returns an empty tree that orders its elements like t.
+func (t *Tree[T]) newEmpty() *Tree[T] {
+	return &Tree[T]{
+		less:    t.less,
+		cmp:     t.cmp,
+		codec:   t.codec,
+		mode:    t.mode,
+		augment: t.augment,
+	}
+}
+
+// blackHeight returns the black height of h.
+func blackHeight[T any](h *TreeNode[T]) int {
+	n := 0
+	for ; h != nil; h = h.Left {
+		if h.Black {
+			n++
+		}
+	}
+	return n
+}
+
+// blacken colors h black and returns its new black height, hh is its black height before.
+func (t *Tree[T]) blacken(h *TreeNode[T], hh int) (*TreeNode[T], int) {
+	if isRed(h) {
+		h = t.mutable(h)
+		h.Black = true
+		hh++
+	}
+	return h, hh
+}
+
+// splitSubtree splits the subtree h, whose root is black with black height hh,
+// into l with the nodes for which toLeft holds and r with the others.
+// toLeft must hold for a prefix of the nodes in order, off is the number of nodes before h.
+// l and r have black roots.
+func (t *Tree[T]) splitSubtree(h *TreeNode[T], hh, off int, toLeft func(h *TreeNode[T], rank int) bool) (l *TreeNode[T], hl int, r *TreeNode[T], hr int) {
+	if h == nil {
+		return nil, 0, nil, 0
+	}
+	left, hLeft := t.blacken(h.Left, hh-1)
+	right, hRight := t.blacken(h.Right, hh-1)
+	rank := off + size(h.Left) + 1
+	if toLeft(h, rank) {
+		var rl *TreeNode[T]
+		var hrl int
+		rl, hrl, r, hr = t.splitSubtree(right, hRight, rank, toLeft)
+		l, hl = t.blacken(t.join(left, h, rl, hLeft, hrl))
+		return l, hl, r, hr
+	}
+	var lr *TreeNode[T]
+	var hlr int
+	l, hl, lr, hlr = t.splitSubtree(left, hLeft, off, toLeft)
+	r, hr = t.blacken(t.join(lr, h, right, hlr, hRight))
+	return l, hl, r, hr
+}
+
+// join2 returns the root of a tree with the nodes of l followed by the nodes of r,
+// l and r must have black roots. The returned root may be red.
+func (t *Tree[T]) join2(l, r *TreeNode[T]) *TreeNode[T] {
+	if l == nil {
+		return r
+	}
+	if r == nil {
+		return l
+	}
+	r, minItem, _ := t.deleteMin(r)
+	r = t.blackRoot(r)
+	root, _ := t.join(l, t.newNode(minItem), r, blackHeight(l), blackHeight(r))
+	return root
+}
+
+// join returns the root of a tree with the nodes of l, the node k and the nodes of r
+// in that order, and its black height. l and r must have black roots with black heights
+// hl and hr, the links of k are overwritten. The returned root may be red.
+func (t *Tree[T]) join(l, k, r *TreeNode[T], hl, hr int) (*TreeNode[T], int) {
+	switch {
+	case hl > hr:
+		return t.joinRight(l, k, r, hl, hr), hl
+	case hl < hr:
+		return t.joinLeft(l, k, r, hl, hr), hr
+	}
+	return t.joinNode(l, k, r), hl
+}
+
+// joinRight joins along the right spine of h, whose black height hh is more than hr.
+func (t *Tree[T]) joinRight(h, k, r *TreeNode[T], hh, hr int) *TreeNode[T] {
+	if hh == hr && !isRed(h) {
+		return t.joinNode(h, k, r)
+	}
+	// in Mode234 a 4-node on the spine is split first, as on the way down of an insert
+	h = t.walkDown(t.mutable(h))
+	if !isRed(h) {
+		hh--
+	}
+	h.Right = t.joinRight(h.Right, k, r, hh, hr)
+	t.update(h)
+	return t.walkUpRot23(h)
+}
+
+// joinLeft joins along the left spine of h, whose black height hh is more than hl.
+func (t *Tree[T]) joinLeft(l, k, h *TreeNode[T], hl, hh int) *TreeNode[T] {
+	if hh == hl && !isRed(h) {
+		return t.joinNode(l, k, h)
+	}
+	// in Mode234 a 4-node on the spine is split first, as on the way down of an insert
+	h = t.walkDown(t.mutable(h))
+	if !isRed(h) {
+		hh--
+	}
+	h.Left = t.joinLeft(l, k, h.Left, hl, hh)
+	t.update(h)
+	return t.walkUpRot23(h)
+}
+
+// joinNode makes k a red node with children l and r.
+func (t *Tree[T]) joinNode(l, k, r *TreeNode[T]) *TreeNode[T] {
+	k = t.mutable(k)
+	k.Left, k.Right, k.Black = l, r, false
+	t.update(k)
+	return k
+}
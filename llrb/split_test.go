@@ -0,0 +1,108 @@
+package llrb
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// checkInvariants fails the test if the tree is not a valid left-leaning red-black tree
+// with correct NDescendants and count, or if it does not hold the items of model.
+func checkInvariants(t *testing.T, tree *LLRB, model []Int) {
+	t.Helper()
+	checkShape(t, tree)
+	if tree.Len() != len(model) {
+		t.Fatalf("expected %v items, len: %v", len(model), tree.Len())
+	}
+	var items []Int
+	tree.Ascend(func(i Item) bool {
+		items = append(items, i.(Int))
+		return true
+	})
+	if len(model) > 0 && !reflect.DeepEqual(items, model) {
+		t.Fatalf("expected %v, reality %v", model, items)
+	}
+}
+
+// checkShape fails the test if the tree is not a valid left-leaning red-black tree
+// with correct NDescendants and count.
+func checkShape(t *testing.T, tree *LLRB) {
+	t.Helper()
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	for k := 0; k < 300; k++ {
+		tree, model := newTestTree(rand.Intn(300))
+		key := Int(rand.Intn(len(model)*2+3) - 1)
+		left, right := tree.Split(key)
+		i := sort.Search(len(model), func(i int) bool { return model[i] >= key })
+		checkInvariants(t, left, model[:i])
+		checkInvariants(t, right, model[i:])
+		checkInvariants(t, tree, nil)
+		if left.GetByRank(i) != nil && left.GetByRank(i) != model[i-1] {
+			t.Fatalf("GetByRank on the left tree")
+		}
+
+		joined := Join(left, right)
+		checkInvariants(t, joined, model)
+		checkInvariants(t, left, nil)
+		checkInvariants(t, right, nil)
+	}
+}
+
+func TestSplitAtRank(t *testing.T) {
+	for k := 0; k < 300; k++ {
+		tree, model := newTestTree(rand.Intn(300))
+		r := rand.Intn(len(model)+3) - 1
+		i := r - 1 // number of items that go left
+		if i < 0 {
+			i = 0
+		}
+		if i > len(model) {
+			i = len(model)
+		}
+		left, right := tree.SplitAtRank(r)
+		checkInvariants(t, left, model[:i])
+		checkInvariants(t, right, model[i:])
+		checkInvariants(t, Join(left, right), model)
+	}
+}
+
+func TestJoin(t *testing.T) {
+	for k := 0; k < 300; k++ {
+		// trees of very different sizes, to join along long spines
+		left, right := New(), New()
+		var model []Int
+		nl, nr := rand.Intn(1<<uint(rand.Intn(10))), rand.Intn(1<<uint(rand.Intn(10)))
+		for i := 0; i < nl+nr; i++ {
+			model = append(model, Int(i))
+		}
+		for _, i := range rand.Perm(nl) {
+			left.InsertNoReplace(Int(i))
+		}
+		for _, i := range rand.Perm(nr) {
+			right.InsertNoReplace(Int(nl + i))
+		}
+		checkInvariants(t, Join(left, right), model)
+	}
+}
+
+func TestJoin_Overlapping(t *testing.T) {
+	left, right := New(), New()
+	left.InsertNoReplaceBulk(Int(1), Int(5))
+	right.InsertNoReplaceBulk(Int(5), Int(6))
+	checkInvariants(t, Join(left, right), []Int{1, 5, 5, 6})
+
+	left.InsertNoReplaceBulk(Int(1), Int(5))
+	right.InsertNoReplaceBulk(Int(4), Int(6))
+	defer func() {
+		if recover() == nil {
+			t.Error("joining overlapping trees must panic")
+		}
+	}()
+	Join(left, right)
+}
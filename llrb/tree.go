@@ -0,0 +1,807 @@
+// Copyright 2010 Petar Maymounkov. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package llrb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Tree is an order statistic tree of values of type T,
+// ordered by the less function given to NewTree.
+// It is the generic core of LLRB: the values are stored unboxed
+// and compared without runtime type assertions.
+type Tree[T any] struct {
+	less  func(a, b T) bool
+	cmp   func(a, b T) int // optional three-way comparison, consistent with less
+	count int
+	root  *TreeNode[T]
+	owner *owner // the nodes with this owner can be modified in place, see Clone and own
+	codec Codec[T]
+	mode  Mode
+
+	augment Augmenter[T] // optional, see SetAugment
+}
+
+// TreeNode is a node of a Tree.
+type TreeNode[T any] struct {
+	Item        T
+	Left, Right *TreeNode[T] // Pointers to left and right child nodes
+	Black       bool         // If set, the color of the link (incoming from the parent) is black
+	// In the LLRB, new nodes are always red, hence the zero-value for node
+
+	// size of the subtree that has root is this Node,
+	// NDescendants == tree_count in for the tree's root Node
+	NDescendants int
+
+	owner     *owner
+	aggregate any // a pointer to the aggregate of the subtree for the Augment of the tree, see SetAugment
+}
+
+// owner marks the nodes that a tree can modify in place. Nodes shared between
+// versions of a tree made by Clone have an owner that no tree has anymore, so they
+// are copied before being modified. A tree that owns no node has the nil owner,
+// and no node has it.
+// It is not zero-sized, so that two owners are never at the same address.
+type owner struct{ _ byte }
+
+// NewTree allocates a new tree that orders its values with less,
+// configured with opts, such as Mode234.
+func NewTree[T any](less func(a, b T) bool, opts ...Option) *Tree[T] {
+	if less == nil {
+		panic("nil less function")
+	}
+	t := &Tree[T]{less: less}
+	t.setOptions(opts)
+	return t
+}
+
+// NewTreeCompare allocates a new tree that orders its values with the three-way
+// comparison cmp, such as cmp.Compare, configured with opts. cmp(a, b) must return a negative number
+// if a is less than b, zero if they have the same order and a positive number otherwise.
+// Lookups, inserts and deletes call cmp once per visited node.
+func NewTreeCompare[T any](cmp func(a, b T) int, opts ...Option) *Tree[T] {
+	if cmp == nil {
+		panic("nil compare function")
+	}
+	t := &Tree[T]{
+		less: func(a, b T) bool { return cmp(a, b) < 0 },
+		cmp:  cmp,
+	}
+	t.setOptions(opts)
+	return t
+}
+
+// SetRoot sets the root node of the tree, and its length to r.NDescendants.
+// It is intended to be used by functions that deserialize the tree.
+func (t *Tree[T]) SetRoot(r *TreeNode[T]) {
+	t.root = r
+	t.count = size(r)
+}
+
+// Root returns the root node of the tree.
+// It is intended to be used by functions that serialize the tree.
+func (t *Tree[T]) Root() *TreeNode[T] {
+	return t.root
+}
+
+// Len returns the number of nodes in the tree.
+func (t *Tree[T]) Len() int { return t.count }
+
+// Clone returns a copy of the tree in O(1). The nodes are shared between t and
+// the copy until one of them modifies them: a modification copies only the nodes
+// on the paths it changes (path copying), so both trees remain valid and
+// independent versions. A tree that was never cloned modifies its nodes in place.
+// t and its copy must not be modified concurrently with each other.
+// Clone does not modify a tree that owns none of its nodes, such as a tree that was
+// not modified since it was cloned, so such a tree can be cloned concurrently.
+func (t *Tree[T]) Clone() *Tree[T] {
+	c := *t
+	c.owner = nil
+	if t.owner != nil { // the nodes of t are shared with c now
+		t.owner = nil
+	}
+	return &c
+}
+
+// own returns the owner of the tree, a new one if the tree owns no node yet.
+func (t *Tree[T]) own() *owner {
+	if t.owner == nil {
+		t.owner = new(owner)
+	}
+	return t.owner
+}
+
+// mutable returns h if the tree can modify it in place, otherwise a copy of h
+// owned by the tree, which the caller must link in place of h.
+func (t *Tree[T]) mutable(h *TreeNode[T]) *TreeNode[T] {
+	if h.owner == t.owner && h.owner != nil {
+		return h
+	}
+	c := *h
+	c.owner = t.own()
+	if t.augment != nil {
+		t.augment.detach(&c)
+	}
+	return &c
+}
+
+// Has returns true if the tree contains an element whose order is the same as that of key.
+func (t *Tree[T]) Has(key T) bool {
+	_, ok := t.Get(key)
+	return ok
+}
+
+// Get retrieves an element from the tree whose order is the same as that of key.
+// ok is false if there is no such element.
+func (t *Tree[T]) Get(key T) (item T, ok bool) {
+	h := t.root
+	for h != nil {
+		switch c := t.compare(key, h.Item); {
+		case c < 0:
+			h = h.Left
+		case c > 0:
+			h = h.Right
+		default:
+			return h.Item, true
+		}
+	}
+	return item, false
+}
+
+// Min returns the minimum element in the tree, ok is false if the tree is empty.
+func (t *Tree[T]) Min() (item T, ok bool) {
+	h := t.root
+	if h == nil {
+		return item, false
+	}
+	for h.Left != nil {
+		h = h.Left
+	}
+	return h.Item, true
+}
+
+// Max returns the maximum element in the tree, ok is false if the tree is empty.
+func (t *Tree[T]) Max() (item T, ok bool) {
+	h := t.root
+	if h == nil {
+		return item, false
+	}
+	for h.Right != nil {
+		h = h.Right
+	}
+	return h.Item, true
+}
+
+func (t *Tree[T]) ReplaceOrInsertBulk(items ...T) {
+	for _, i := range items {
+		t.ReplaceOrInsert(i)
+	}
+}
+
+func (t *Tree[T]) InsertNoReplaceBulk(items ...T) {
+	for _, i := range items {
+		t.InsertNoReplace(i)
+	}
+}
+
+// ReplaceOrInsert inserts item into the tree. If an existing
+// element has the same order, it is removed from the tree and returned
+// with ok set to true.
+func (t *Tree[T]) ReplaceOrInsert(item T) (replaced T, ok bool) {
+	replaced, ok = t.insert(item, true)
+	if !ok {
+		t.count++
+	}
+	return replaced, ok
+}
+
+// InsertNoReplace inserts item into the tree. If an existing
+// element has the same order, both elements remain in the tree.
+func (t *Tree[T]) InsertNoReplace(item T) {
+	t.insert(item, false)
+	t.count++
+}
+
+// insert inserts item, if replace is set an element with the same order
+// is replaced and returned with ok set to true. It goes down the tree
+// calling walkDown and back up calling walkUp on the nodes of its path.
+func (t *Tree[T]) insert(item T, replace bool) (replaced T, ok bool) {
+	var buf [maxHeight]*TreeNode[T]
+	path := buf[:0]
+	link := &t.root
+	for *link != nil {
+		h := t.walkDown(t.mutable(*link))
+		*link = h
+		path = append(path, h)
+		if !replace {
+			if t.less(item, h.Item) {
+				link = &h.Left
+			} else {
+				link = &h.Right
+			}
+			continue
+		}
+		if c := t.compare(item, h.Item); c < 0 {
+			link = &h.Left
+		} else if c > 0 {
+			link = &h.Right
+		} else {
+			replaced, h.Item, ok = h.Item, item, true
+			break
+		}
+	}
+	if !ok {
+		*link = t.newNode(item)
+	}
+	t.root = t.up(t.root, path, t.walkUp)
+	t.root.Black = true
+	return replaced, ok
+}
+
+// up goes back up path, the nodes from root down to a modified subtree, updates
+// each of them and links fix(h) in place of each node h. It returns the new root.
+func (t *Tree[T]) up(root *TreeNode[T], path []*TreeNode[T], fix func(h *TreeNode[T]) *TreeNode[T]) *TreeNode[T] {
+	for i := len(path) - 1; i >= 0; i-- {
+		h := path[i]
+		t.update(h)
+		x := fix(h)
+		switch {
+		case i == 0:
+			root = x
+		case path[i-1].Left == h:
+			path[i-1].Left = x
+		default:
+			path[i-1].Right = x
+		}
+	}
+	return root
+}
+
+// walkDown and walkUp call the rotation driver routines of the mode of the tree
+func (t *Tree[T]) walkDown(h *TreeNode[T]) *TreeNode[T] {
+	if t.mode == Mode234 {
+		return t.walkDownRot234(h)
+	}
+	return t.walkDownRot23(h)
+}
+
+func (t *Tree[T]) walkUp(h *TreeNode[T]) *TreeNode[T] {
+	if t.mode == Mode234 {
+		return t.walkUpRot234(h)
+	}
+	return t.walkUpRot23(h)
+}
+
+// Rotation driver routines for 2-3 algorithm
+
+// walkDownRot23 does nothing
+func (t *Tree[T]) walkDownRot23(h *TreeNode[T]) *TreeNode[T] { return h }
+
+func (t *Tree[T]) walkUpRot23(h *TreeNode[T]) *TreeNode[T] {
+	if isRed(h.Right) && !isRed(h.Left) {
+		h = t.rotateLeft(h)
+	}
+
+	if isRed(h.Left) && isRed(h.Left.Left) {
+		h = t.rotateRight(h)
+	}
+
+	if isRed(h.Left) && isRed(h.Right) {
+		t.flip(h)
+	}
+
+	return h
+}
+
+// Rotation driver routines for 2-3-4 algorithm
+
+func (t *Tree[T]) walkDownRot234(h *TreeNode[T]) *TreeNode[T] {
+	if isRed(h.Left) && isRed(h.Right) {
+		t.flip(h)
+	}
+
+	return h
+}
+
+func (t *Tree[T]) walkUpRot234(h *TreeNode[T]) *TreeNode[T] {
+	if isRed(h.Right) && !isRed(h.Left) {
+		h = t.rotateLeft(h)
+	}
+
+	if isRed(h.Left) && isRed(h.Left.Left) {
+		h = t.rotateRight(h)
+	}
+
+	return h
+}
+
+// DeleteMin deletes the minimum element in the tree and returns the
+// deleted item, ok is false if the tree is empty.
+func (t *Tree[T]) DeleteMin() (deleted T, ok bool) {
+	t.root, deleted, ok = t.deleteMin(t.root)
+	t.blackenRoot()
+	if ok {
+		t.count--
+	}
+	return deleted, ok
+}
+
+// deleteMin deletes the minimum of the subtree h and returns its new root.
+func (t *Tree[T]) deleteMin(h *TreeNode[T]) (*TreeNode[T], T, bool) {
+	return t.remove(h, nil)
+}
+
+// DeleteMax deletes the maximum element in the tree and returns
+// the deleted item, ok is false if the tree is empty.
+func (t *Tree[T]) DeleteMax() (deleted T, ok bool) {
+	t.root, deleted, ok = t.remove(t.root, seekMax[T])
+	t.blackenRoot()
+	if ok {
+		t.count--
+	}
+	return deleted, ok
+}
+
+// Delete deletes an item from the tree whose key equals key.
+// The deleted item is returned, ok is false if there is no such item.
+func (t *Tree[T]) Delete(key T) (deleted T, ok bool) {
+	t.root, deleted, ok = t.remove(t.root, func(h *TreeNode[T], _ int) int {
+		return t.compare(key, h.Item)
+	})
+	t.blackenRoot()
+	if ok {
+		t.count--
+	}
+	return deleted, ok
+}
+
+// DeleteByRank deletes the item with rank r (rank start from 1) from the tree
+// and returns it, ok is false if r is not in [1, t.Len()].
+// Unlike looking the item up and deleting it by key, this always deletes
+// the item at rank r, even if it has duplicates.
+func (t *Tree[T]) DeleteByRank(r int) (deleted T, ok bool) {
+	if r < 1 || r > t.count {
+		return deleted, false
+	}
+	t.root, deleted, _ = t.remove(t.root, func(_ *TreeNode[T], rank int) int {
+		return r - rank
+	})
+	t.blackenRoot()
+	t.count--
+	return deleted, true
+}
+
+// seekMax is the seek function of remove for the maximum.
+func seekMax[T any](h *TreeNode[T], _ int) int {
+	if h.Right == nil {
+		return 0
+	}
+	return 1
+}
+
+// remove deletes a node of the subtree h and returns its new root, with the deleted item.
+// seek(x, rank) tells where the node is relative to the node x, whose rank in
+// the subtree is rank: a negative number if it is on the left, zero if it is x
+// and a positive number if it is on the right, ok is false if it is not found.
+// A nil seek deletes the minimum.
+// Like the recursive delete of LLRB, remove makes sure on the way down that the
+// next node is not a 2-node, with moveRedLeft and moveRedRight, and calls fixUp
+// on the nodes of its path on the way back up. A node found with a right child
+// takes the item of its successor, which is deleted instead.
+func (t *Tree[T]) remove(h *TreeNode[T], seek func(x *TreeNode[T], rank int) int) (root *TreeNode[T], deleted T, ok bool) {
+	var buf [maxHeight]*TreeNode[T]
+	path := buf[:0]
+	var found *TreeNode[T] // the node that takes the item of its successor
+	root = h
+	link := &root
+	off := 0 // the number of elements before the subtree *link
+	for *link != nil {
+		h = t.mutable(*link)
+		c := t.seekAt(h, off, seek)
+		if c < 0 {
+			if h.Left == nil { // not present, nothing to delete
+				*link = h
+				break
+			}
+			if !isRed(h.Left) && !isRed(h.Left.Left) {
+				h = t.moveRedLeft(h)
+			}
+			*link = h
+			path = append(path, h)
+			link = &h.Left
+			continue
+		}
+		// c is kept as the order of the target relative to h,
+		// it is only computed again when a rotation changes h
+		if t.leansLeft(h) {
+			h = t.rotateRight(h)
+			c = t.seekAt(h, off, seek)
+		}
+		if c == 0 && h.Right == nil {
+			*link = nil
+			deleted, ok = h.Item, true
+			break
+		}
+		if h.Right != nil && !isRed(h.Right) && !isRed(h.Right.Left) {
+			// if moveRedRight rotates, the old h moves into the right subtree,
+			// the target is not before it so it is looked for there,
+			// even if it equals the new h.Item (a duplicate)
+			if x := t.moveRedRight(h); x != h {
+				h, c = x, 1
+			}
+		}
+		*link = h
+		path = append(path, h)
+		if c == 0 {
+			found, seek = h, nil
+		}
+		off += size(h.Left) + 1
+		link = &h.Right
+	}
+	if found != nil {
+		deleted, found.Item = found.Item, deleted
+	}
+	return t.up(root, path, t.fixUp), deleted, ok
+}
+
+// seekAt calls the seek function of remove on h, off is the number of elements before h.
+func (t *Tree[T]) seekAt(h *TreeNode[T], off int, seek func(x *TreeNode[T], rank int) int) int {
+	if seek != nil {
+		return seek(h, off+size(h.Left)+1)
+	}
+	if h.Left == nil {
+		return 0
+	}
+	return -1
+}
+
+// Internal node manipulation routines
+
+// compare returns a negative number, zero or a positive number if a is less than,
+// has the same order as or is greater than b. It calls the three-way comparison
+// of the tree once if there is one, otherwise it calls less once or twice.
+func (t *Tree[T]) compare(a, b T) int {
+	if t.cmp != nil {
+		return t.cmp(a, b)
+	}
+	if t.less(a, b) {
+		return -1
+	}
+	if t.less(b, a) {
+		return 1
+	}
+	return 0
+}
+
+func (t *Tree[T]) newNode(item T) *TreeNode[T] {
+	h := &TreeNode[T]{Item: item, owner: t.own()}
+	t.update(h)
+	return h
+}
+
+// update recomputes the augmentations of h from its item and its children:
+// the sizes that every tree keeps, then the aggregate of the Augment of the tree.
+// The nodes whose subtrees change are updated from the bottom up.
+func (t *Tree[T]) update(h *TreeNode[T]) {
+	sizes[T]{}.update(h)
+	t.aggregate(h)
+}
+
+// aggregate recomputes the aggregate of h for the Augment of the tree, if there is one.
+func (t *Tree[T]) aggregate(h *TreeNode[T]) {
+	if t.augment != nil {
+		t.augment.update(h)
+	}
+}
+
+func isRed[T any](h *TreeNode[T]) bool {
+	if h == nil {
+		return false
+	}
+	return !h.Black
+}
+
+// rotateLeft and rotateRight copy the nodes they modify if the tree does not own them
+func (t *Tree[T]) rotateLeft(h *TreeNode[T]) *TreeNode[T] {
+	h = t.mutable(h)
+	x := t.mutable(h.Right)
+	if x.Black {
+		panic("rotating a black link")
+	}
+	h.Right = x.Left
+	x.Left = h
+	x.Black = h.Black
+	h.Black = false
+
+	t.update(h)
+	t.update(x)
+
+	return x
+}
+
+func (t *Tree[T]) rotateRight(h *TreeNode[T]) *TreeNode[T] {
+	h = t.mutable(h)
+	x := t.mutable(h.Left)
+	if x.Black {
+		panic("rotating a black link")
+	}
+	h.Left = x.Right
+	x.Right = h
+	x.Black = h.Black
+	h.Black = false
+
+	t.update(h)
+	t.update(x)
+
+	return x
+}
+
+// flip changes color of the node and its children,
+// only nodes's color are changed, they do not need an update,
+// the children are copied if the tree does not own them,
+// REQUIRE: Left and Right children must be present, the tree must own h
+func (t *Tree[T]) flip(h *TreeNode[T]) {
+	h.Left, h.Right = t.mutable(h.Left), t.mutable(h.Right)
+	h.Black = !h.Black
+	h.Left.Black = !h.Left.Black
+	h.Right.Black = !h.Right.Black
+}
+
+// REQUIRE: Left and Right children must be present
+func (t *Tree[T]) moveRedLeft(h *TreeNode[T]) *TreeNode[T] {
+	t.flip(h)
+	if isRed(h.Right.Left) {
+		h.Right = t.rotateRight(h.Right)
+		h = t.rotateLeft(h)
+		t.flip(h)
+	}
+	return h
+}
+
+// REQUIRE: Left and Right children must be present
+func (t *Tree[T]) moveRedRight(h *TreeNode[T]) *TreeNode[T] {
+	t.flip(h)
+	if isRed(h.Left.Left) {
+		h = t.rotateRight(h)
+		t.flip(h)
+	}
+	return h
+}
+
+func (t *Tree[T]) fixUp(h *TreeNode[T]) *TreeNode[T] {
+	if t.mode == Mode234 {
+		return t.fixUp234(h)
+	}
+	if isRed(h.Right) {
+		h = t.rotateLeft(h)
+	}
+
+	if isRed(h.Left) && isRed(h.Left.Left) {
+		h = t.rotateRight(h)
+	}
+
+	if isRed(h.Left) && isRed(h.Right) {
+		t.flip(h)
+	}
+
+	return h
+}
+
+// leansLeft tells whether a delete going right from h must first rotate the red
+// left link of h to the right. A 4-node of Mode234 already has a red right link,
+// rotating it would make a chain of two red right links.
+func (t *Tree[T]) leansLeft(h *TreeNode[T]) bool {
+	return isRed(h.Left) && (t.mode != Mode234 || !isRed(h.Right))
+}
+
+// fixUp234 is fixUp for Mode234. The flips and rotations of a delete may leave h
+// and the nodes linked to it by red links (its cluster) in any shape, and also
+// the clusters of the black nodes right below it. fixUp234 rebuilds them as
+// 2-, 3- or 4-nodes, a cluster of more than 3 nodes is split in two
+// with a red root for the parent to fix. A red h is left as it is,
+// it is fixed with the cluster of its black parent,
+// REQUIRE: the subtrees of the cluster have the same black height
+func (t *Tree[T]) fixUp234(h *TreeNode[T]) *TreeNode[T] {
+	if isRed(h) || t.is234Node(h, true) {
+		return h
+	}
+	var nodes [7]*TreeNode[T]
+	var subtrees [8]*TreeNode[T]
+	k, s := 0, 0
+	var collect func(x *TreeNode[T])
+	visit := func(c *TreeNode[T]) {
+		if c != nil && !c.Black {
+			collect(c)
+			return
+		}
+		if c != nil && !t.is234Node(c, false) {
+			if c = t.fixUp234(c); isRed(c) {
+				collect(c)
+				return
+			}
+		}
+		subtrees[s], s = c, s+1
+	}
+	collect = func(x *TreeNode[T]) {
+		visit(x.Left)
+		if k == len(nodes) {
+			panic("a cluster of more than 7 nodes")
+		}
+		nodes[k], k = t.mutable(x), k+1
+		visit(x.Right)
+	}
+	collect(h)
+	if k <= 3 {
+		return t.cluster(nodes[:k], subtrees[:k+1])
+	}
+	m := (k - 1) / 2
+	return t.link(nodes[m], t.cluster(nodes[:m], subtrees[:m+1]), t.cluster(nodes[m+1:k], subtrees[m+1:k+1]), false)
+}
+
+// is234Node tells whether the black node h and its red children are a valid 2-, 3- or 4-node,
+// and if below is set, whether the black nodes right below them are too.
+func (t *Tree[T]) is234Node(h *TreeNode[T], below bool) bool {
+	if isRed(h.Right) && !isRed(h.Left) {
+		return false
+	}
+	for _, c := range [...]*TreeNode[T]{h.Left, h.Right} {
+		switch {
+		case c == nil:
+		case !c.Black:
+			if isRed(c.Left) || isRed(c.Right) {
+				return false
+			}
+			if below && (c.Left != nil && !t.is234Node(c.Left, false) || c.Right != nil && !t.is234Node(c.Right, false)) {
+				return false
+			}
+		case below && !t.is234Node(c, false):
+			return false
+		}
+	}
+	return true
+}
+
+// cluster links the 1 to 3 nodes as a 2-, 3- or 4-node with a black root,
+// subtrees are the subtrees between them.
+func (t *Tree[T]) cluster(nodes, subtrees []*TreeNode[T]) *TreeNode[T] {
+	switch len(nodes) {
+	case 1:
+		return t.link(nodes[0], subtrees[0], subtrees[1], true)
+	case 2:
+		l := t.link(nodes[0], subtrees[0], subtrees[1], false)
+		return t.link(nodes[1], l, subtrees[2], true)
+	}
+	l := t.link(nodes[0], subtrees[0], subtrees[1], false)
+	r := t.link(nodes[2], subtrees[2], subtrees[3], false)
+	return t.link(nodes[1], l, r, true)
+}
+
+// link sets the children and the color of x, which the tree must own, and returns it.
+func (t *Tree[T]) link(x, l, r *TreeNode[T], black bool) *TreeNode[T] {
+	x.Left, x.Right, x.Black = l, r, black
+	t.update(x)
+	return x
+}
+
+// blackenRoot colors the root black after a delete, see blackRoot.
+func (t *Tree[T]) blackenRoot() {
+	t.root = t.blackRoot(t.root)
+}
+
+// blackRoot colors h, the root of a tree returned by a delete, black and returns
+// the new root. In Mode234, the cluster of a red root was not fixed by fixUp234,
+// so it is fixed now.
+func (t *Tree[T]) blackRoot(h *TreeNode[T]) *TreeNode[T] {
+	if !isRed(h) {
+		return h
+	}
+	h.Black = true
+	if t.mode == Mode234 {
+		h = t.fixUp234(h)
+		h.Black = true
+	}
+	return h
+}
+
+// maxHeight is the most nodes on a path from the root down to a leaf, the size of
+// the path arrays of the iterative walks. The height of a red-black tree of n nodes
+// is at most 2*log2(n+1) and n is an int.
+const maxHeight = 2 * strconv.IntSize
+
+// size is convenient to get node_NDescendants (node can be nil)
+func size[T any](h *TreeNode[T]) int {
+	if h == nil {
+		return 0
+	}
+	return h.NDescendants
+}
+
+func (h *TreeNode[T]) String() string {
+	if h != nil {
+		return fmt.Sprintf("[k:%v,%v,%v]",
+			h.Item, h.NDescendants, h.Black)
+	} else {
+		return "nil"
+	}
+}
+
+func (t *Tree[T]) stringBFS() string {
+	lines := make([]string, 0)
+	visiteds := make(map[*TreeNode[T]]bool, t.count)
+	type QueueElem struct {
+		node   *TreeNode[T]
+		parent string
+	}
+	q := []QueueElem{{node: t.root, parent: "IAmRoot"}}
+	for len(q) > 0 {
+		pop := q[0]
+		q = q[1:]
+		visiteds[pop.node] = true
+		parentStr := fmt.Sprintf("%v", pop.node.Item)
+		if pop.node.Left != nil && !visiteds[pop.node.Left] {
+			q = append(q, QueueElem{node: pop.node.Left, parent: parentStr})
+		}
+		if pop.node.Right != nil && !visiteds[pop.node.Right] {
+			q = append(q, QueueElem{node: pop.node.Right, parent: parentStr})
+		}
+		line := fmt.Sprintf("parent: %v, node: %v, ", pop.parent, pop.node)
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// GetByRank retrieves the item with a given rank r (rank start from 1),
+// ok is false if r is not in [1, t.Len()].
+func (t *Tree[T]) GetByRank(r int) (item T, ok bool) {
+	node := t.getByRank(t.root, r)
+	if node == nil {
+		return item, false
+	}
+	return node.Item, true
+}
+
+func (t *Tree[T]) getByRank(h *TreeNode[T], r int) *TreeNode[T] {
+	for h != nil {
+		hRank := size(h.Left) + 1
+		if r == hRank {
+			return h
+		}
+		if r < hRank {
+			h = h.Left
+		} else {
+			h = h.Right
+			r -= hRank
+		}
+	}
+	return nil
+}
+
+// GetRankOf determines rank of an key (rank start from 1),
+// this func returns the rank and the first item in the tree that equal to key,
+// that is LowerBoundRank(key)+1. In case key does not exist, this func returns
+// the rank key would have if it were inserted and ok is false,
+// the rank of any key in an empty tree is 0.
+// It takes a single descent and does not allocate.
+func (t *Tree[T]) GetRankOf(key T) (rank int, item T, ok bool) {
+	if t.root == nil {
+		return 0, item, false
+	}
+	var first *TreeNode[T] // the first element greater or equal to key
+	for h := t.root; h != nil; {
+		if t.less(h.Item, key) {
+			rank += size(h.Left) + 1
+			h = h.Right
+		} else {
+			first = h
+			h = h.Left
+		}
+	}
+	if first != nil && !t.less(key, first.Item) {
+		return rank + 1, first.Item, true
+	}
+	return rank + 1, item, false
+}
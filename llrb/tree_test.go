@@ -0,0 +1,177 @@
+package llrb
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func lessInt(a, b int) bool { return a < b }
+
+// testTree is the API that the scenarios of llrb_test.go use, so that each of them
+// runs against an LLRB and against a Tree of the same elements, see forEachTree.
+type testTree[K Item] interface {
+	ReplaceOrInsert(item K) (replaced K, ok bool)
+	InsertNoReplace(item K)
+	Delete(key K) (deleted K, ok bool)
+	DeleteMin() (deleted K, ok bool)
+	DeleteMax() (deleted K, ok bool)
+	Has(key K) bool
+	Len() int
+	GetByRank(r int) (item K, ok bool)
+	GetRankOf(key K) (rank int, item K, ok bool)
+	AscendGreaterOrEqual(pivot K, iterator func(item K) bool)
+	AscendRange(greaterOrEqual, lessThan K, iterator func(item K) bool)
+	HeightStats() (avg, stddev float64)
+	// sizeAt returns the NDescendants of the node at path from the root,
+	// e.g. "LR" for the right child of the left child of the root.
+	sizeAt(path string) int
+	stringBFS() string
+}
+
+// forEachTree runs test on an empty LLRB and on an empty Tree of the Items
+// of type K, ordered by their Less method.
+func forEachTree[K Item](t *testing.T, test func(t *testing.T, tree testTree[K])) {
+	t.Run("LLRB", func(t *testing.T) { test(t, itemTree[K]{New()}) })
+	t.Run("Tree", func(t *testing.T) {
+		test(t, plainTree[K]{NewTree(func(a, b K) bool { return a.Less(b) })})
+	})
+}
+
+// plainTree is a Tree as a testTree.
+type plainTree[K Item] struct{ *Tree[K] }
+
+func (t plainTree[K]) sizeAt(path string) int { return sizeAt(t.root, path) }
+
+// itemTree is an LLRB as a testTree, its Items are all of type K.
+type itemTree[K Item] struct{ *LLRB }
+
+func unbox[K Item](item Item) (K, bool) {
+	k, ok := item.(K)
+	return k, ok
+}
+
+func unboxed[K Item](iterator func(item K) bool) ItemIterator {
+	return func(i Item) bool { return iterator(i.(K)) }
+}
+
+func (t itemTree[K]) ReplaceOrInsert(item K) (K, bool) { return unbox[K](t.LLRB.ReplaceOrInsert(item)) }
+func (t itemTree[K]) InsertNoReplace(item K)           { t.LLRB.InsertNoReplace(item) }
+func (t itemTree[K]) Delete(key K) (K, bool)           { return unbox[K](t.LLRB.Delete(key)) }
+func (t itemTree[K]) DeleteMin() (K, bool)             { return unbox[K](t.LLRB.DeleteMin()) }
+func (t itemTree[K]) DeleteMax() (K, bool)             { return unbox[K](t.LLRB.DeleteMax()) }
+func (t itemTree[K]) Has(key K) bool                   { return t.LLRB.Has(key) }
+func (t itemTree[K]) GetByRank(r int) (K, bool)        { return unbox[K](t.LLRB.GetByRank(r)) }
+func (t itemTree[K]) sizeAt(path string) int           { return sizeAt(t.root, path) }
+
+func (t itemTree[K]) GetRankOf(key K) (int, K, bool) {
+	rank, item := t.LLRB.GetRankOf(key)
+	k, ok := unbox[K](item)
+	return rank, k, ok
+}
+
+func (t itemTree[K]) AscendGreaterOrEqual(pivot K, iterator func(item K) bool) {
+	t.LLRB.AscendGreaterOrEqual(pivot, unboxed(iterator))
+}
+
+func (t itemTree[K]) AscendRange(greaterOrEqual, lessThan K, iterator func(item K) bool) {
+	t.LLRB.AscendRange(greaterOrEqual, lessThan, unboxed(iterator))
+}
+
+func sizeAt[T any](h *TreeNode[T], path string) int {
+	for _, c := range path {
+		if h == nil {
+			break
+		}
+		if c == 'L' {
+			h = h.Left
+		} else {
+			h = h.Right
+		}
+	}
+	return size(h)
+}
+
+// Unlike LLRB.GetByRank, which returns the minimum or the maximum,
+// Tree.GetByRank has no element for the ranks out of [1, Len()].
+func TestTree_GetByRankOutOfRange(t *testing.T) {
+	tree := NewTree(lessInt)
+	tree.InsertNoReplace(10)
+	if _, ok := tree.GetByRank(0); ok {
+		t.Error("rank 0 must not exist")
+	}
+	if _, ok := tree.GetByRank(2); ok {
+		t.Error("rank 2 must not exist")
+	}
+}
+
+// The walks, lookups and deletes are iterative on a fixed-size path array,
+// so they must not allocate, an insert allocates its node only.
+func TestTree_Allocs(t *testing.T) {
+	tree := NewTree(lessInt)
+	for i := 0; i < 1000; i++ {
+		tree.InsertNoReplace(i)
+	}
+	visit := func(int) bool { return true }
+	for _, c := range []struct {
+		name   string
+		allocs float64
+		f      func()
+	}{
+		{"Ascend", 0, func() { tree.Ascend(visit) }},
+		{"AscendRange", 0, func() { tree.AscendRange(10, 500, visit) }},
+		{"DescendLessOrEqual", 0, func() { tree.DescendLessOrEqual(700, visit) }},
+		{"AscendRankRange", 0, func() { tree.AscendRankRange(10, 500, visit) }},
+		{"DescendRankRange", 0, func() { tree.DescendRankRange(500, 10, visit) }},
+		{"All", 0, func() {
+			for range tree.All() {
+			}
+		}},
+		{"GetByRank", 0, func() { tree.GetByRank(77) }},
+		{"GetRankOf", 0, func() { tree.GetRankOf(77) }},
+		{"ReplaceOrInsert existing", 0, func() { tree.ReplaceOrInsert(77) }},
+		{"Delete and InsertNoReplace", 1, func() {
+			tree.Delete(77)
+			tree.InsertNoReplace(77)
+		}},
+		{"DeleteMin and InsertNoReplace", 1, func() {
+			min, _ := tree.DeleteMin()
+			tree.InsertNoReplace(min)
+		}},
+		{"DeleteMax and InsertNoReplace", 1, func() {
+			max, _ := tree.DeleteMax()
+			tree.InsertNoReplace(max)
+		}},
+		{"DeleteByRank and InsertNoReplace", 1, func() {
+			item, _ := tree.DeleteByRank(500)
+			tree.InsertNoReplace(item)
+		}},
+	} {
+		if got := testing.AllocsPerRun(100, c.f); got != c.allocs {
+			t.Errorf("%s: %v allocations, expected %v", c.name, got, c.allocs)
+		}
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func BenchmarkTree_Insert(b *testing.B) {
+	tree := NewTree(lessInt)
+	for i := 0; i < b.N; i++ {
+		tree.ReplaceOrInsert(b.N - i)
+	}
+}
+
+func BenchmarkTree_GetRankOf(b *testing.B) {
+	b.StopTimer()
+	tree := NewTree(lessInt)
+	for i := 0; i < b.N; i++ {
+		tree.InsertNoReplace(i)
+	}
+	b.ReportAllocs()
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		tree.GetRankOf(i)
+		tree.GetByRank(rand.Intn(b.N))
+	}
+}
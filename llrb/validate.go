@@ -0,0 +1,100 @@
+package llrb
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Validate checks that the tree is a valid left-leaning red-black tree: its elements
+// are in order, the root is black, no red link leans right (except the right link
+// of a 4-node in Mode234), no two red links are in a row, every path from the root
+// to a leaf has the same number of black links, NDescendants is the size of the subtree
+// of every node and Len is the size of the tree.
+// It is meant for tests and for trees installed with SetRoot, it runs in O(n).
+// The error describes the first broken rule and where the bad node is.
+func (t *Tree[T]) Validate() error {
+	v := validator[T]{t: t, limit: max(t.count, size(t.root))}
+	if isRed(t.root) {
+		return v.errorf(t.root, "the root is red")
+	}
+	if _, err := v.check(t.root); err != nil {
+		return err
+	}
+	if t.count != size(t.root) {
+		return fmt.Errorf("llrb: Len() is %d but the root holds %d elements", t.count, size(t.root))
+	}
+	return nil
+}
+
+type validator[T any] struct {
+	t       *Tree[T]
+	path    []byte // the links from the root to the current node, 'L' or 'R'
+	visited int
+	limit   int // more visited nodes than limit means that the graph is not a tree
+	prev    *TreeNode[T]
+}
+
+// check checks the subtree h and returns its black height.
+func (v *validator[T]) check(h *TreeNode[T]) (int, error) {
+	if h == nil {
+		return 0, nil
+	}
+	if v.visited++; v.visited > v.limit {
+		return 0, v.errorf(h, "more nodes than Len() and the NDescendants of the root, a node is shared or in a cycle")
+	}
+	if isRed(h.Right) {
+		if v.t.mode != Mode234 {
+			return 0, v.errorf(h, "the right link is red")
+		}
+		if !isRed(h.Left) {
+			return 0, v.errorf(h, "the right link is red and the left one is black")
+		}
+	}
+	if isRed(h) && isRed(h.Left) {
+		return 0, v.errorf(h, "the node and its left child are both red")
+	}
+
+	v.path = append(v.path, 'L')
+	hl, err := v.check(h.Left)
+	v.path = v.path[:len(v.path)-1]
+	if err != nil {
+		return 0, err
+	}
+
+	if v.prev != nil && v.t.less(h.Item, v.prev.Item) {
+		return 0, v.errorf(h, "out of order, the previous element is %v", v.prev.Item)
+	}
+	v.prev = h
+
+	v.path = append(v.path, 'R')
+	hr, err := v.check(h.Right)
+	v.path = v.path[:len(v.path)-1]
+	if err != nil {
+		return 0, err
+	}
+
+	if hl != hr {
+		return 0, v.errorf(h, "the left subtree has black height %d, the right one %d", hl, hr)
+	}
+	if n := size(h.Left) + size(h.Right) + 1; h.NDescendants != n {
+		return 0, v.errorf(h, "NDescendants is %d, the subtree has %d nodes", h.NDescendants, n)
+	}
+	if h.Black {
+		hl++
+	}
+	return hl, nil
+}
+
+// errorf returns an error about the node h, at the end of v.path.
+func (v *validator[T]) errorf(h *TreeNode[T], format string, args ...any) error {
+	var path strings.Builder
+	path.WriteString("root")
+	for _, c := range v.path {
+		if c == 'L' {
+			path.WriteString(".Left")
+		} else {
+			path.WriteString(".Right")
+		}
+	}
+	return fmt.Errorf("llrb: invalid node %v at %s: %s", h, path.String(), fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,64 @@
+package llrb
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	for _, c := range []struct {
+		name    string
+		corrupt func(tree *LLRB)
+		err     string
+	}{
+		{"valid", func(tree *LLRB) {}, ""},
+		{"red root", func(tree *LLRB) { tree.root.Black = false }, "at root: the root is red"},
+		{"right-leaning red", func(tree *LLRB) { tree.root.Right.Black = false }, "at root: the right link is red"},
+		{"two reds", func(tree *LLRB) {
+			tree.root.Left.Black = false
+			tree.root.Left.Left.Black = false
+		}, "at root.Left: the node and its left child are both red"},
+		{"black height", func(tree *LLRB) { tree.root.Left.Left.Black = false }, "at root.Left: the left subtree has black height"},
+		{"order", func(tree *LLRB) { tree.root.Right.Left.Item = Int(1) }, "at root.Right.Left: out of order"},
+		{"NDescendants", func(tree *LLRB) { tree.root.Left.Right.NDescendants = 2 }, "at root.Left.Right: NDescendants is 2, the subtree has 1 nodes"},
+		{"count", func(tree *LLRB) { tree.count = 6 }, "Len() is 6 but the root holds 7 elements"},
+		{"cycle", func(tree *LLRB) { tree.root.Left.Left.Left = tree.root }, "a node is shared or in a cycle"},
+	} {
+		tree := New()
+		for i := 1; i <= 7; i++ {
+			tree.InsertNoReplace(Int(i))
+		}
+		c.corrupt(tree)
+		err := tree.Validate()
+		if c.err == "" && err != nil || c.err != "" && (err == nil || !strings.Contains(err.Error(), c.err)) {
+			t.Errorf("%v: expected an error with %q, reality: %v", c.name, c.err, err)
+		}
+	}
+}
+
+func TestValidate_SetRoot(t *testing.T) {
+	// a tree rebuilt from its nodes, the way a deserializer does
+	src := New()
+	for i := 1; i <= 7; i++ {
+		src.InsertNoReplace(Int(i))
+	}
+	var copyNode func(h *Node) *Node
+	copyNode = func(h *Node) *Node {
+		if h == nil {
+			return nil
+		}
+		return &Node{Item: h.Item, Left: copyNode(h.Left), Right: copyNode(h.Right), Black: h.Black, NDescendants: h.NDescendants}
+	}
+	tree := New()
+	tree.SetRoot(copyNode(src.Root()))
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if tree.Len() != 7 {
+		t.Errorf("Len: %v, expected 7", tree.Len())
+	}
+	tree.SetRoot(nil)
+	if err := tree.Validate(); err != nil || tree.Len() != 0 {
+		t.Errorf("after SetRoot(nil): Len %v, %v", tree.Len(), err)
+	}
+}
@@ -0,0 +1,105 @@
+package llrb
+
+// Weighter is an optional interface of the elements weighed by the Weights of NewWeights.
+// An element that does not implement it weighs 1.
+type Weighter interface {
+	// Weight returns the weight of the element, it must not be negative
+	// and must not change while the element is in a tree.
+	Weight() int64
+}
+
+// Weights is the Augment that keeps the sum of the weights of the elements of each
+// subtree, for SelectByWeight, PrefixWeight, RangeWeight and TotalWeight:
+//
+//	tree.SetAugment(NewWeights[Item](nil))
+//	item, rank := tree.SelectByWeight(w)
+//
+// The queries are the weighted forms of GetByRank and CountLess, they run in O(log n)
+// and panic if the augmentation of the tree is not a Weights.
+type Weights[T any] struct {
+	*Augment[T, int64]
+}
+
+// NewWeights returns the Weights of the elements weighed by weigh,
+// or by their Weighter interface if weigh is nil.
+func NewWeights[T any](weigh func(item T) int64) *Weights[T] {
+	if weigh == nil {
+		weigh = itemWeight[T]
+	}
+	return &Weights[T]{NewAugment(func(item T, left, right int64) int64 {
+		return left + weigh(item) + right
+	})}
+}
+
+func itemWeight[T any](item T) int64 {
+	if w, ok := any(item).(Weighter); ok {
+		return w.Weight()
+	}
+	return 1
+}
+
+// own returns the weight of the element of h.
+func (w *Weights[T]) own(h *TreeNode[T]) int64 {
+	return w.Of(h) - w.Of(h.Left) - w.Of(h.Right)
+}
+
+// weights returns the Weights of the tree.
+func (t *Tree[T]) weights() *Weights[T] {
+	w, ok := t.augment.(*Weights[T])
+	if !ok {
+		panic("llrb: the tree is not augmented with Weights")
+	}
+	return w
+}
+
+// TotalWeight returns the sum of the weights of all elements.
+func (t *Tree[T]) TotalWeight() int64 {
+	return t.weights().Of(t.root)
+}
+
+// SelectByWeight returns the element where the running total of the weights,
+// in ascending order, passes w: the element e for which
+// PrefixWeight(e) <= w < PrefixWeight(e) + e.Weight(), with its rank.
+// Elements that weigh 0 are never selected. ok is false if w is not in [0, TotalWeight()).
+func (t *Tree[T]) SelectByWeight(w int64) (item T, rank int, ok bool) {
+	weights := t.weights()
+	if w < 0 {
+		return item, 0, false
+	}
+	for h := t.root; h != nil; {
+		if l := weights.Of(h.Left); w < l {
+			h = h.Left
+		} else if w -= l; w < weights.own(h) {
+			return h.Item, rank + size(h.Left) + 1, true
+		} else {
+			w -= weights.own(h)
+			rank += size(h.Left) + 1
+			h = h.Right
+		}
+	}
+	return item, 0, false
+}
+
+// PrefixWeight returns the sum of the weights of the elements less than key.
+func (t *Tree[T]) PrefixWeight(key T) int64 {
+	weights := t.weights()
+	var w int64
+	for h := t.root; h != nil; {
+		if t.less(h.Item, key) {
+			w += weights.Of(h) - weights.Of(h.Right)
+			h = h.Right
+		} else {
+			h = h.Left
+		}
+	}
+	return w
+}
+
+// RangeWeight returns the sum of the weights of the elements greater or equal
+// to greaterOrEqual and less than lessThan.
+func (t *Tree[T]) RangeWeight(greaterOrEqual, lessThan T) int64 {
+	if w := t.PrefixWeight(lessThan) - t.PrefixWeight(greaterOrEqual); w > 0 {
+		return w
+	}
+	return 0
+}
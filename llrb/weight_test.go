@@ -0,0 +1,134 @@
+package llrb
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// weighted is an Item that weighs its value modulo 4, so some weigh 0.
+type weighted int
+
+func (w weighted) Less(than Item) bool { return w < than.(weighted) }
+
+func (w weighted) Weight() int64 { return int64(w % 4) }
+
+// checkWeights compares the weight queries of tree with the model, the sorted Items of tree.
+func checkWeights(t *testing.T, tree *LLRB, model []weighted) {
+	t.Helper()
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	var total int64
+	prefix := make([]int64, len(model)) // prefix[i] is the weight of model[:i]
+	for i, m := range model {
+		prefix[i] = total
+		total += m.Weight()
+	}
+	if got := tree.TotalWeight(); got != total {
+		t.Fatalf("TotalWeight: %v, expected %v", got, total)
+	}
+	for w := int64(-1); w <= total; w++ {
+		i := sort.Search(len(model), func(i int) bool { return prefix[i]+model[i].Weight() > w })
+		item, rank := tree.SelectByWeight(w)
+		if w < 0 || w == total {
+			if item != nil {
+				t.Fatalf("SelectByWeight(%v): %v, expected nil", w, item)
+			}
+			continue
+		}
+		if item != model[i] || rank != i+1 {
+			t.Fatalf("SelectByWeight(%v): %v, %v, expected %v, %v", w, item, rank, model[i], i+1)
+		}
+	}
+	for k := 0; k < 10; k++ {
+		lo, hi := weighted(rand.Intn(60)), weighted(rand.Intn(60))
+		var want int64
+		for _, m := range model {
+			if lo <= m && m < hi {
+				want += m.Weight()
+			}
+		}
+		if got := tree.RangeWeight(lo, hi); got != want {
+			t.Fatalf("RangeWeight(%v, %v): %v, expected %v", lo, hi, got, want)
+		}
+		want = total
+		if i := sort.Search(len(model), func(i int) bool { return model[i] >= hi }); i < len(model) {
+			want = prefix[i]
+		}
+		if got := tree.PrefixWeight(hi); got != want {
+			t.Fatalf("PrefixWeight(%v): %v, expected %v", hi, got, want)
+		}
+	}
+}
+
+func TestWeights(t *testing.T) {
+	for _, mode := range []Mode{Mode23, Mode234} {
+		tree := NewWithOptions(mode)
+		tree.SetAugment(NewWeights[Item](nil))
+		var model []weighted
+		for k := 0; k < 1000; k++ {
+			key := weighted(rand.Intn(50))
+			i := sort.Search(len(model), func(i int) bool { return model[i] >= key })
+			switch rand.Intn(6) {
+			case 0, 1:
+				tree.InsertNoReplace(key)
+				i = sort.Search(len(model), func(i int) bool { return model[i] > key })
+				model = append(model[:i], append([]weighted{key}, model[i:]...)...)
+			case 2:
+				if tree.Delete(key) != nil {
+					model = append(model[:i], model[i+1:]...)
+				}
+			case 3:
+				if tree.DeleteMin() != nil {
+					model = model[1:]
+				}
+			case 4:
+				if r := 1 + rand.Intn(len(model)+1); tree.DeleteByRank(r) != nil {
+					model = append(model[:r-1], model[r:]...)
+				}
+			case 5:
+				clone := tree.Clone()
+				tree = Join(clone.Split(key))
+			}
+			checkWeights(t, tree, model)
+		}
+	}
+}
+
+func TestWeights_Default(t *testing.T) {
+	// elements that do not implement Weighter weigh 1
+	tree := NewTree(lessInt)
+	tree.SetAugment(NewWeights[int](nil))
+	tree.AppendSorted([]int{10, 20, 30, 40})
+	if got := tree.RangeWeight(15, 40); got != 2 {
+		t.Errorf("RangeWeight: %v, expected 2", got)
+	}
+	if item, rank, ok := tree.SelectByWeight(2); !ok || item != 30 || rank != 3 {
+		t.Errorf("SelectByWeight(2): %v, %v, %v", item, rank, ok)
+	}
+}
+
+func TestWeights_Func(t *testing.T) {
+	tree := NewTree(lessInt)
+	tree.AppendSorted([]int{10, 20, 30, 40})
+	tree.SetAugment(NewWeights(func(i int) int64 { return int64(i) }))
+	if got := tree.TotalWeight(); got != 100 {
+		t.Errorf("TotalWeight: %v, expected 100", got)
+	}
+	if got := tree.PrefixWeight(35); got != 60 {
+		t.Errorf("PrefixWeight(35): %v, expected 60", got)
+	}
+	if item, rank, ok := tree.SelectByWeight(60); !ok || item != 40 || rank != 4 {
+		t.Errorf("SelectByWeight(60): %v, %v, %v", item, rank, ok)
+	}
+}
+
+func TestWeights_NotWeighted(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic")
+		}
+	}()
+	New().TotalWeight()
+}